@@ -8,14 +8,20 @@ package sqlmx
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/cookieY/sqlx"
+	"github.com/gnodux/sqlmx/dialect"
 	"github.com/gnodux/sqlmx/expr"
 	. "github.com/gnodux/sqlmx/meta"
 	. "github.com/gnodux/sqlmx/utils"
 	"reflect"
+	"strings"
 	"sync"
 )
 
+// DefaultInsertChunkSize 默认多行插入时每条语句的行数
+var DefaultInsertChunkSize = 500
+
 // BaseMapper 基础的ORM功能
 // 1. 默认的CRUD操作
 // 2. 表达式查询
@@ -31,6 +37,14 @@ type BaseMapper[T any] struct {
 	PartialUpdateTx TxFunc `sql:"builtin/partial_update_by_id_tenant_id.sql" readonly:"false" tx:"Default"`
 	DeleteTx        TxFunc `sql:"builtin/delete_by_id.sql" readonly:"false" tx:"Default"`
 	EraseTx         TxFunc `sql:"builtin/erase_by_id.sql" readonly:"false" tx:"Default"`
+	//InsertChunkSize 多行插入时每条语句的行数，为0时使用DefaultInsertChunkSize
+	InsertChunkSize int
+	//ConsecutiveIds 同一条语句插入的多行自增ID连续且LastInsertId为第一行的ID(例如MySQL的innodb_autoinc_lock_mode为0或1)，
+	//为true时多行插入使用LastInsertId回填主键
+	ConsecutiveIds bool
+	//InsertPerRow 多行插入无法确定每行的主键时(没有唯一键、方言不能按顺序返回主键且未设置ConsecutiveIds)，
+	//为true时逐行插入以回填主键，默认仍然批量插入，但不回填主键
+	InsertPerRow bool
 }
 
 func (b *BaseMapper[T]) init() {
@@ -74,6 +88,8 @@ func (b *BaseMapper[T]) ListById(tenantId any, ids ...any) (entities []T, err er
 	if err != nil {
 		return
 	}
+	//sqlx.In 生成的是?占位符，需要转换为当前驱动的占位符(例如Postgres的$1)
+	query = b.Rebind(query)
 	if stmt, err = b.Preparex(query); err != nil {
 		return
 	}
@@ -223,6 +239,9 @@ func (b *BaseMapper[T]) EraseById(tenantId any, ids ...any) error {
 	})
 }
 
+// Create 使用内置模版插入数据，并回填自增主键
+//
+// 支持RETURNING/OUTPUT的方言(Postgres、SQLServer)通过返回的主键回填，其他方言使用LastInsertId
 func (b *BaseMapper[T]) Create(entities ...T) error {
 
 	if len(entities) == 0 {
@@ -232,10 +251,19 @@ func (b *BaseMapper[T]) Create(entities ...T) error {
 		return tx.RunCurrentPrepareNamed(b.meta, func(stmt *sqlx.NamedStmt) error {
 			var result sql.Result
 			for idx, _ := range entities {
-				if result, err = stmt.Exec(entities[idx]); err != nil {
+				if b.meta.PrimaryKey != nil && b.driver.SupportReturning() {
+					//使用实体接收返回的主键，按照字段的实际类型扫描(例如UUID)
+					var returned []T
+					if err = stmt.Select(&returned, entities[idx]); err != nil {
+						return err
+					}
+					if len(returned) > 0 {
+						copyPrimaryKey(&entities[idx], &returned[0], b.meta)
+					}
+				} else if result, err = stmt.Exec(entities[idx]); err != nil {
+					return err
+				} else if err = setPrimaryKey(&entities[idx], b.meta, result); err != nil {
 					return err
-				} else {
-					err = setPrimaryKey(&entities[idx], b.meta, result)
 				}
 			}
 			return nil
@@ -245,13 +273,9 @@ func (b *BaseMapper[T]) Create(entities ...T) error {
 
 // Select 使用SelectExprBuilder构建查询
 // 默认限制100条,如果需要更多,请使用builder中的Limit方法
+// 可以通过expr.UseJoin或expr.SelectFilter添加连接查询，通过expr.UseUnion等添加复合查询
 func (b *BaseMapper[T]) Select(builders ...expr.FilterFn) (result []T, total int64, err error) {
-
-	//默认Limit 100
-	queryExpr := expr.Select(b.meta.ColumnExprs()...).From(b.meta).Limit(100)
-	for _, fn := range builders {
-		fn(queryExpr)
-	}
+	queryExpr := b.buildSelect(builders...)
 	err = b.SelectExpr(&result, queryExpr)
 	if err != nil {
 		return
@@ -263,6 +287,40 @@ func (b *BaseMapper[T]) Select(builders ...expr.FilterFn) (result []T, total int
 	return
 }
 
+// SelectTx 在事务中使用SelectExprBuilder构建查询，规则与Select一致
+// 可以通过expr.UseForUpdate或expr.UseLock对查询的行加锁，锁在事务结束时释放
+func (b *BaseMapper[T]) SelectTx(tx *Tx, builders ...expr.FilterFn) (result []T, total int64, err error) {
+	queryExpr := b.buildSelect(builders...)
+	err = tx.SelectExpr(&result, queryExpr)
+	if err != nil {
+		return
+	}
+	if queryExpr.UseCount() {
+		countExpr := queryExpr.BuildCountExpr()
+		err = tx.GetExpr(&total, countExpr)
+	}
+	return
+}
+
+func (b *BaseMapper[T]) buildSelect(builders ...expr.FilterFn) *expr.SelectExpr {
+	b.init()
+	//默认Limit 100
+	queryExpr := expr.Select(b.meta.ColumnExprs()...).From(b.meta).Limit(100)
+	defaultColumns := queryExpr.Columns
+	for _, fn := range builders {
+		fn(queryExpr)
+	}
+	//连接查询时，默认列使用表名(FROM使用别名时为别名)限定，避免列名冲突
+	if len(queryExpr.Joins) > 0 && queryExpr.Columns == defaultColumns {
+		if from, ok := queryExpr.FromExpr.(expr.AliasedTable); ok {
+			queryExpr.Select(b.meta.As(from.TableAlias()).ColumnExprs()...)
+		} else {
+			queryExpr.Select(b.meta.QualifiedColumnExprs()...)
+		}
+	}
+	return queryExpr
+}
+
 func (b *BaseMapper[T]) InsertExpr(builders ...expr.InsertFilterFn) error {
 	insertExpr := expr.InsertInto(b.meta)
 	for _, fn := range builders {
@@ -275,28 +333,220 @@ func (b *BaseMapper[T]) InsertExpr(builders ...expr.InsertFilterFn) error {
 // Insert 插入数据,如果有主键,会自动填充主键
 //
 // 和Create不一样的是，Insert会忽略空值，仅插入有值的字段
+//
+// 插入字段相同的连续实体会合并为多行插入，每条语句的行数由InsertChunkSize和方言的参数数量限制决定
+// 多行插入时主键的回填规则参见execInsert，无法确定每行主键时需要设置ConsecutiveIds或InsertPerRow
 func (b *BaseMapper[T]) Insert(entities ...T) error {
+	b.init()
+	if len(entities) == 0 {
+		return nil
+	}
+	rows := make([]map[string]any, len(entities))
+	for idx := range entities {
+		rows[idx] = ToMap(entities[idx])
+	}
+	return b.CreateTx(func(tx *Tx) error {
+		for start := 0; start < len(entities); {
+			cols := b.insertColumns(rows[start])
+			end := start + 1
+			for end < len(entities) && sameColumns(cols, b.insertColumns(rows[end])) {
+				end++
+			}
+			var colExprs []expr.Expr
+			for _, col := range cols {
+				colExprs = append(colExprs, col)
+			}
+			insertExpr := expr.InsertInto(b.meta).Columns(colExprs...)
+			for idx := start; idx < end; idx++ {
+				var values []expr.Expr
+				for _, col := range cols {
+					values = append(values, expr.Var(fmt.Sprintf("%s_%d", col.Name, idx-start), rows[idx][col.Name]))
+				}
+				insertExpr.Row(values...)
+			}
+			//主键有值时不需要回填
+			backfill := !Contains(cols, func(col *Column) bool { return col.IsPrimaryKey })
+			for _, chunk := range insertExpr.Split(b.driver.InsertChunkSize(len(cols), b.chunkSize())) {
+				if err := b.execInsert(tx, chunk, entities[start:start+chunk.RowCount()], backfill); err != nil {
+					return err
+				}
+				start += chunk.RowCount()
+			}
+		}
+		return nil
+	})
+}
+
+// insertColumns 按照实体定义的顺序返回有值的列
+func (b *BaseMapper[T]) insertColumns(values map[string]any) []*Column {
+	return Search(b.meta.Columns, func(col *Column) bool {
+		_, ok := values[col.Name]
+		return ok && !col.Ignore
+	})
+}
+
+func (b *BaseMapper[T]) chunkSize() int {
+	if b.InsertChunkSize > 0 {
+		return b.InsertChunkSize
+	}
+	return DefaultInsertChunkSize
+}
+
+func sameColumns(a, b []*Column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// Upsert 插入数据，如果唯一键冲突则更新其他列，唯一键使用第一组dbx:"unique=组名"声明的列，没有唯一键时使用主键
+//
+// 和Insert一样，仅处理有值的字段；冲突检测列、主键和租户ID不会被更新
+func (b *BaseMapper[T]) Upsert(entities ...T) error {
+	b.init()
+	if len(entities) == 0 {
+		return sql.ErrNoRows
+	}
+	conflictKeys := b.meta.ConflictKeys()
+	if len(conflictKeys) == 0 {
+		return errors.New("upsert must have a primary key or unique key")
+	}
+	var conflictCols []expr.Expr
+	for _, col := range conflictKeys {
+		conflictCols = append(conflictCols, col)
+	}
 	return b.CreateTx(func(tx *Tx) error {
-		for idx, _ := range entities {
-			insertExpr := expr.InsertInto(b.meta)
+		for idx := range entities {
+			upsertExpr := expr.Upsert(b.meta, conflictCols...)
+			if pk := b.meta.PrimaryKey; pk != nil && pk.IsIdentity() {
+				upsertExpr.Identity(pk)
+			}
+			//按照实体定义的列顺序生成语句，保证相同的实体生成相同的SQL
 			values := ToMap(entities[idx])
-			for k, v := range values {
-				col := b.meta.Column(k)
-				if col != nil {
-					insertExpr.SetExpr(col, expr.Var(k, v))
+			for _, col := range b.insertColumns(values) {
+				upsertExpr.SetExpr(col, expr.Var(col.Name, values[col.Name]))
+				if !col.IsPrimaryKey && !col.IsTenantKey && !Contains(conflictKeys, func(c *Column) bool { return c == col }) {
+					upsertExpr.DoUpdateExcluded(col)
 				}
 			}
-			result, err := tx.ExecExpr(insertExpr)
-			if err != nil {
+			if err := b.execInsert(tx, upsertExpr, entities[idx:idx+1], true); err != nil {
 				return err
-			} else {
-				if err = setPrimaryKey(&entities[idx], b.meta, result); err != nil {
+			}
+		}
+		return nil
+	})
+}
+
+// execInsert 执行插入语句，backfill为true时回填主键
+//
+// 单行插入时使用RETURNING/OUTPUT返回的主键或LastInsertId回填。多行插入时按照以下顺序确定每行的主键：
+//  1. 插入的列包含唯一键时，返回(不支持RETURNING/OUTPUT的方言查询)主键和唯一键，按唯一键匹配实体
+//  2. Postgres的RETURNING按照VALUES的顺序返回，按顺序回填
+//  3. 设置了ConsecutiveIds时使用LastInsertId+n回填
+//  4. 设置了InsertPerRow时逐行插入，否则不回填主键
+func (b *BaseMapper[T]) execInsert(tx *Tx, insertExpr *expr.InsertExpr, entities []T, backfill bool) error {
+	if !backfill || b.meta.PrimaryKey == nil {
+		_, err := tx.ExecExpr(insertExpr)
+		return err
+	}
+	var keys []*Column
+	if insertExpr.RowCount() > 1 {
+		keys = b.returningKeys(insertExpr)
+		ordered := b.driver.Returning == dialect.ReturningClause || (!b.driver.SupportReturning() && b.ConsecutiveIds)
+		if len(keys) == 0 && !ordered {
+			if !b.InsertPerRow {
+				_, err := tx.ExecExpr(insertExpr)
+				return err
+			}
+			for idx, rowExpr := range insertExpr.Split(1) {
+				if err := b.execInsert(tx, rowExpr, entities[idx:idx+1], backfill); err != nil {
 					return err
 				}
 			}
+			return nil
+		}
+	}
+	if !b.driver.SupportReturning() {
+		result, err := tx.ExecExpr(insertExpr)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 || b.ConsecutiveIds {
+			return setPrimaryKeys(entities, b.meta, result)
+		}
+		return b.selectPrimaryKeys(tx, entities, keys)
+	}
+	returning := []expr.Expr{b.meta.PrimaryKey}
+	for _, key := range keys {
+		returning = append(returning, key)
+	}
+	//使用实体接收返回的数据，主键按照字段的实际类型扫描(例如UUID)
+	var returned []T
+	if err := tx.SelectExpr(&returned, insertExpr.Returning(returning...)); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		for idx := 0; idx < len(returned) && idx < len(entities); idx++ {
+			copyPrimaryKey(&entities[idx], &returned[idx], b.meta)
 		}
 		return nil
-	})
+	}
+	backfillByKeys(entities, returned, keys, b.meta)
+	return nil
+}
+
+// selectPrimaryKeys 多行插入后通过唯一键查询插入行的主键
+func (b *BaseMapper[T]) selectPrimaryKeys(tx *Tx, entities []T, keys []*Column) error {
+	columns := []expr.Expr{b.meta.PrimaryKey}
+	for _, key := range keys {
+		columns = append(columns, key)
+	}
+	var conds []expr.Expr
+	for idx := range entities {
+		ev := entityValue(&entities[idx])
+		var eqs []expr.Expr
+		for _, key := range keys {
+			eqs = append(eqs, expr.Eq(key, expr.Var(fmt.Sprintf("%s_%d", key.Name, idx), ev.FieldByName(key.Name).Interface())))
+		}
+		conds = append(conds, expr.Paren(expr.And(eqs...)))
+	}
+	var inserted []T
+	if err := tx.SelectExpr(&inserted, expr.Select(columns...).From(b.meta).Where(expr.Or(conds...))); err != nil {
+		return err
+	}
+	backfillByKeys(entities, inserted, keys, b.meta)
+	return nil
+}
+
+// backfillByKeys 按照唯一键匹配实体，使用rows中的主键回填
+func backfillByKeys[T any](entities, rows []T, keys []*Column, meta *Entity) {
+	index := make(map[string]int, len(entities))
+	for idx := range entities {
+		index[uniqueValue(&entities[idx], keys)] = idx
+	}
+	for idx := range rows {
+		if i, ok := index[uniqueValue(&rows[idx], keys)]; ok {
+			copyPrimaryKey(&entities[i], &rows[idx], meta)
+		}
+	}
+}
+
+// returningKeys 插入列中包含的唯一键(不含主键)，用于匹配返回或查询到的行
+func (b *BaseMapper[T]) returningKeys(insertExpr *expr.InsertExpr) []*Column {
+	for _, group := range b.meta.UniqueGroups {
+		if Contains(group, func(col *Column) bool {
+			return col.IsPrimaryKey || !Contains(insertExpr.ColumnExprs, func(exp expr.Expr) bool { return exp == col })
+		}) {
+			continue
+		}
+		return group
+	}
+	return nil
 }
 
 func (b *BaseMapper[T]) CountBy(where map[string]any, fns ...expr.FilterFn) (total int64, err error) {
@@ -351,6 +601,20 @@ func (b *BaseMapper[T]) UpdateBy(builders ...expr.FilterFn) (effect int64, err e
 	effect, err = result.RowsAffected()
 	return
 }
+
+// UpdateByReturning 和UpdateBy一样，但是返回更新后的数据，方言需要支持RETURNING/OUTPUT(Postgres、SQLServer)
+func (b *BaseMapper[T]) UpdateByReturning(builders ...expr.FilterFn) (result []T, err error) {
+	if !b.driver.SupportReturning() {
+		return nil, ErrReturningNotSupported
+	}
+	updateExpr := expr.Update(b.meta)
+	for _, fn := range builders {
+		fn(updateExpr)
+	}
+	err = b.SelectExpr(&result, updateExpr.Returning(b.meta.ColumnExprs()...))
+	return
+}
+
 func (b *BaseMapper[T]) UpdateByExample(newValue T, example T, builders ...expr.FilterFn) (effect int64, err error) {
 	if err = EvalBeforeHook(newValue); err != nil {
 		return 0, err
@@ -397,6 +661,23 @@ func (b *BaseMapper[T]) DeleteBy(builders ...expr.DeleteExprFn) (rowAffected int
 	rowAffected, err = result.RowsAffected()
 	return
 }
+
+// DeleteByReturning 和DeleteBy一样，但是返回被删除的数据，方言需要支持RETURNING/OUTPUT(Postgres、SQLServer)
+func (b *BaseMapper[T]) DeleteByReturning(builders ...expr.DeleteExprFn) (result []T, err error) {
+	if len(builders) == 0 {
+		return nil, errors.New("delete by must have one builder")
+	}
+	if !b.driver.SupportReturning() {
+		return nil, ErrReturningNotSupported
+	}
+	deleteExpr := expr.Delete(b.meta)
+	for _, fn := range builders {
+		fn(deleteExpr)
+	}
+	err = b.SelectExpr(&result, deleteExpr.Returning(b.meta.ColumnExprs()...))
+	return
+}
+
 func (b *BaseMapper[T]) DeleteByExample(example T, builders ...expr.DeleteExprFn) (effect int64, err error) {
 	valMap := ToMap(example)
 	var whereColumns []expr.Expr
@@ -418,18 +699,67 @@ func setPrimaryKey(entity any, meta *Entity, result sql.Result) error {
 	id, err := result.LastInsertId()
 	if err != nil {
 		return err
-	} else {
-		ev := reflect.ValueOf(entity)
+	} else if id > 0 {
+		setPrimaryKeyValue(entity, meta, id)
+	}
+	return nil
+}
+
+// setPrimaryKeys 插入后使用LastInsertId回填主键
+// 多行插入时LastInsertId为第一行的自增ID，调用者需要确认同一条语句插入的行自增ID连续(ConsecutiveIds)
+func setPrimaryKeys[T any](entities []T, meta *Entity, result sql.Result) error {
+	if meta.PrimaryKey == nil {
+		return nil
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if id > 0 {
+		for idx := range entities {
+			setPrimaryKeyValue(&entities[idx], meta, id+int64(idx))
+		}
+	}
+	return nil
+}
+
+func setPrimaryKeyValue(entity any, meta *Entity, id int64) {
+	pkf := entityValue(entity).FieldByName(meta.PrimaryKey.Name)
+	if pkf.IsValid() && pkf.CanSet() && pkf.CanInt() {
+		pkf.SetInt(id)
+	}
+}
+
+// copyPrimaryKey 将src(RETURNING/OUTPUT返回的行)的主键复制到dst
+func copyPrimaryKey(dst, src any, meta *Entity) {
+	pkf := entityValue(dst).FieldByName(meta.PrimaryKey.Name)
+	srcf := entityValue(src).FieldByName(meta.PrimaryKey.Name)
+	if pkf.IsValid() && pkf.CanSet() && srcf.IsValid() {
+		pkf.Set(srcf)
+	}
+}
+
+// uniqueValue 实体唯一键的值，用于匹配插入的实体和返回的行
+func uniqueValue(entity any, keys []*Column) string {
+	ev := entityValue(entity)
+	var sb strings.Builder
+	for _, key := range keys {
+		if f := reflect.Indirect(ev.FieldByName(key.Name)); f.IsValid() {
+			fmt.Fprintf(&sb, "%v", f.Interface())
+		}
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+// entityValue 实体的结构体值，entity为实体或实体指针的指针
+func entityValue(entity any) reflect.Value {
+	ev := reflect.ValueOf(entity)
+	if ev.Kind() == reflect.Pointer {
+		ev = ev.Elem()
 		if ev.Kind() == reflect.Pointer {
 			ev = ev.Elem()
-			if ev.Kind() == reflect.Pointer {
-				ev = ev.Elem()
-			}
-		}
-		pkf := ev.FieldByName(meta.PrimaryKey.Name)
-		if pkf.IsValid() && pkf.CanSet() && pkf.CanInt() {
-			pkf.SetInt(id)
 		}
 	}
-	return nil
+	return ev
 }
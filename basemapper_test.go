@@ -9,6 +9,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/gnodux/sqlmx/dialect"
 	"github.com/gnodux/sqlmx/expr"
 	"github.com/gnodux/sqlmx/meta"
 	"github.com/gnodux/sqlmx/utils"
@@ -392,3 +393,64 @@ func TestBaseMapper_Insert(t *testing.T) {
 	}
 
 }
+
+func TestBaseMapper_Upsert(t *testing.T) {
+	mapper, err := NewMapper[BaseMapper[*User]](currentDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newUser := User{
+		Name:     "test upsert user",
+		TenantID: 100102,
+		Password: fmt.Sprintf("%d", rand.Int63n(99999)),
+		Birthday: time.Now(),
+		Address:  "Room 404,Build 401, 302 Road,Beijing",
+		Role:     "user",
+	}
+	err = mapper.Upsert(&newUser)
+	assert.NoError(t, err)
+	assert.Greater(t, newUser.ID, int64(0))
+
+	newUser.Address = "Room 412,DONGFENG KASO,JIUXIANQIAO Road,ChaoYang, BeiJing"
+	err = mapper.Upsert(&newUser)
+	assert.NoError(t, err)
+	users, _, err := mapper.SelectByExample(&User{ID: newUser.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, newUser.Address, users[0].Address)
+	}
+	_, err = mapper.DeleteBy(func(e *expr.DeleteExpr) {
+		e.Where(expr.N("id").Eq(newUser.ID))
+	})
+	assert.NoError(t, err)
+}
+
+func TestBuildSelectJoinColumns(t *testing.T) {
+	mapper := &BaseMapper[*Role]{}
+	u := meta.NewEntity(&User{}).As("u")
+	tests := []struct {
+		name string
+		fns  []expr.FilterFn
+		want string
+	}{
+		{
+			name: "table name",
+			fns:  []expr.FilterFn{expr.UseJoin(expr.InnerJoin(u, expr.Eq(u.Col("role"), mapper.Meta().Col("name"))))},
+			want: "SELECT `role`.`id`,`role`.`name`,`role`.`desc`,`role`.`is_deleted` FROM `role` INNER JOIN `user` AS `u` ON `u`.`role` = `role`.`name`",
+		}, {
+			name: "aliased from",
+			fns: []expr.FilterFn{expr.SelectFilter(func(s *expr.SelectExpr) {
+				r := mapper.Meta().As("r")
+				s.From(r).InnerJoin(u, expr.Eq(u.Col("role"), r.Col("name")))
+			})},
+			want: "SELECT `r`.`id`,`r`.`name`,`r`.`desc`,`r`.`is_deleted` FROM `role` AS `r` INNER JOIN `user` AS `u` ON `u`.`role` = `r`.`name`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _, err := expr.NewTracedBuffer(dialect.MySQL).BuildNamed(mapper.buildSelect(tt.fns...).Limit(0))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, query)
+		})
+	}
+}
@@ -1,4 +1,4 @@
 INSERT INTO {{n .TableName}}
 ({{columns .Columns}})
-VALUES
-({{args .Columns}})
\ No newline at end of file
+{{output .PrimaryKey}}VALUES
+({{args .Columns}}){{returning .PrimaryKey}}
\ No newline at end of file
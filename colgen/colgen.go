@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+// Package colgen 根据实体结构体生成类型安全的列引用(meta.TypedColumn)
+//
+// 由于需要通过反射(meta.ListColumns)读取实体，生成器通常放在实体所在目录下的一个//go:build ignore程序中：
+//
+//	//go:build ignore
+//
+//	package main
+//
+//	func main() {
+//		if err := colgen.WriteFile("cols_gen.go", models.User{}, models.Order{}); err != nil {
+//			panic(err)
+//		}
+//	}
+//
+// 然后在实体包中添加：//go:generate go run gen.go，生成的代码可以这样使用：
+//
+//	mapper.Select(expr.UseCondition(expr.And(UserCols.Name.Eq("tom"), UserCols.Age.Ge(18))))
+package colgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/gnodux/sqlmx/meta"
+)
+
+const metaPkg = "github.com/gnodux/sqlmx/meta"
+
+var tpl = template.Must(template.New("cols").Parse(`// Code generated by sqlmx colgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+{{range .Entities}}
+// {{.Type}}Cols {{.Type}}的列
+var {{.Type}}Cols = func() (cols struct {
+	//Entity 实体元数据，可以作为表使用：expr.Select(...).From({{.Type}}Cols.Entity)
+	Entity *meta.Entity
+{{- range .Columns}}
+	{{.Name}} meta.TypedColumn[{{.Type}}]
+{{- end}}
+}) {
+	cols.Entity = meta.NewEntity(&{{.Type}}{})
+{{- range .Columns}}
+	cols.{{.Name}} = meta.NewTypedColumn[{{.Type}}](cols.Entity, "{{.Name}}")
+{{- end}}
+	return
+}()
+{{end}}`))
+
+type genColumn struct {
+	Name string
+	Type string
+}
+
+type genEntity struct {
+	Type    string
+	Columns []genColumn
+}
+
+type genFile struct {
+	Package  string
+	Imports  []string
+	Entities []genEntity
+}
+
+// Generate 生成entities的列引用代码，entities必须是同一个包中的结构体(或结构体指针)
+func Generate(entities ...any) ([]byte, error) {
+	if len(entities) == 0 {
+		return nil, errors.New("no entity to generate")
+	}
+	file := &genFile{}
+	var pkgPath string
+	imports := map[string]bool{metaPkg: true}
+	for _, entity := range entities {
+		t := reflect.TypeOf(entity)
+		if t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct || t.Name() == "" {
+			return nil, fmt.Errorf("entity must be a named struct, got %T", entity)
+		}
+		if pkgPath == "" {
+			pkgPath = t.PkgPath()
+			file.Package = packageName(t)
+		} else if t.PkgPath() != pkgPath {
+			return nil, fmt.Errorf("entity %s is not in package %s", t, pkgPath)
+		}
+		e := genEntity{Type: t.Name()}
+		for _, col := range meta.ListColumns(t) {
+			if col.Ignore {
+				continue
+			}
+			if col.Name == "Entity" {
+				return nil, fmt.Errorf("field %s.Entity conflicts with the generated Entity field", t.Name())
+			}
+			e.Columns = append(e.Columns, genColumn{Name: col.Name, Type: typeName(col.Type, pkgPath, imports)})
+		}
+		file.Entities = append(file.Entities, e)
+	}
+	for imp := range imports {
+		file.Imports = append(file.Imports, imp)
+	}
+	sort.Strings(file.Imports)
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, file); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// WriteFile 生成entities的列引用代码并写入文件
+func WriteFile(filename string, entities ...any) error {
+	src, err := Generate(entities...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, src, 0644)
+}
+
+// packageName 类型所在包的名称，例如：models.User => models
+func packageName(t reflect.Type) string {
+	name, _, _ := strings.Cut(t.String(), ".")
+	return name
+}
+
+// typeName 类型在生成代码中的名称，其他包中的类型会记录到imports
+func typeName(t reflect.Type, pkgPath string, imports map[string]bool) string {
+	if t.Name() != "" {
+		switch t.PkgPath() {
+		case "":
+			return t.Name()
+		case pkgPath:
+			return t.Name()
+		}
+		imports[t.PkgPath()] = true
+		return packageName(t) + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeName(t.Elem(), pkgPath, imports)
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem(), pkgPath, imports)
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeName(t.Elem(), pkgPath, imports))
+	case reflect.Map:
+		return "map[" + typeName(t.Key(), pkgPath, imports) + "]" + typeName(t.Elem(), pkgPath, imports)
+	}
+	return t.String()
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package colgen
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Status int
+
+type Base struct {
+	Id        int64
+	CreatedAt time.Time
+}
+
+type User struct {
+	Base
+	Name     string
+	Nickname *string
+	Status   Status
+	Email    sql.NullString
+	Tags     []string
+	Password string `dbx:"_"`
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate(&User{})
+	assert.NoError(t, err)
+	assert.Equal(t, `// Code generated by sqlmx colgen. DO NOT EDIT.
+
+package colgen
+
+import (
+	"database/sql"
+	"github.com/gnodux/sqlmx/meta"
+	"time"
+)
+
+// UserCols User的列
+var UserCols = func() (cols struct {
+	//Entity 实体元数据，可以作为表使用：expr.Select(...).From(UserCols.Entity)
+	Entity    *meta.Entity
+	Id        meta.TypedColumn[int64]
+	CreatedAt meta.TypedColumn[time.Time]
+	Name      meta.TypedColumn[string]
+	Nickname  meta.TypedColumn[*string]
+	Status    meta.TypedColumn[Status]
+	Email     meta.TypedColumn[sql.NullString]
+	Tags      meta.TypedColumn[[]string]
+}) {
+	cols.Entity = meta.NewEntity(&User{})
+	cols.Id = meta.NewTypedColumn[int64](cols.Entity, "Id")
+	cols.CreatedAt = meta.NewTypedColumn[time.Time](cols.Entity, "CreatedAt")
+	cols.Name = meta.NewTypedColumn[string](cols.Entity, "Name")
+	cols.Nickname = meta.NewTypedColumn[*string](cols.Entity, "Nickname")
+	cols.Status = meta.NewTypedColumn[Status](cols.Entity, "Status")
+	cols.Email = meta.NewTypedColumn[sql.NullString](cols.Entity, "Email")
+	cols.Tags = meta.NewTypedColumn[[]string](cols.Entity, "Tags")
+	return
+}()
+`, string(src))
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	_, err := Generate()
+	assert.Error(t, err)
+	_, err = Generate(1)
+	assert.Error(t, err)
+	_, err = Generate(User{}, sql.NullString{})
+	assert.Error(t, err)
+}
@@ -9,18 +9,24 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/cookieY/sqlx"
 	"github.com/gnodux/sqlmx/dialect"
 	"github.com/gnodux/sqlmx/expr"
 	"io/fs"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 )
 
 var (
 	ErrNilDriver = errors.New("driver is nil")
 	ErrNilDB     = errors.New("DB is nil")
+	//ErrReturningNotSupported 方言不支持RETURNING/OUTPUT
+	ErrReturningNotSupported = errors.New("returning is not supported by the dialect")
+	//ErrBindArgs 模版使用了bind绑定参数，但调用方式(预编译、命名参数、ParseSQL)无法传递绑定的参数
+	ErrBindArgs = errors.New("bind args are not supported here, use SelectEx, GetEx or ExecEx")
 )
 
 // DB 数据库连接
@@ -28,10 +34,17 @@ var (
 // 1. 通过模板文件生成SQL语句并执行，每个连接实例都可以拥有自己的模版系统
 // 2. 通过expr包提供的表达式语法生成SQL语句并执行
 type DB struct {
-	m        *DBManager
-	template *template.Template
-	lock     sync.Mutex
-	driver   *dialect.Dialect
+	m *DBManager
+	//template 模版集合，热加载时整体替换，执行时无需加锁
+	template atomic.Pointer[template.Template]
+	//tplVersion 模版集合的版本，模版变化时递增，用于淘汰bindPool中过期的副本
+	tplVersion atomic.Uint64
+	//bindPool 参数绑定使用的模版副本(*boundTemplate)，避免每次执行都复制模版集合
+	bindPool sync.Pool
+	//metas 模版名称=>模版元数据(*TplMeta)
+	metas  sync.Map
+	lock   sync.Mutex
+	driver *dialect.Dialect
 	*sqlx.DB
 }
 
@@ -39,6 +52,7 @@ func (d *DB) SetManager(m *DBManager) {
 	d.m = m
 }
 
+// PrepareEx 解析模版并预编译语句，模版使用了bind时返回ErrBindArgs
 func (d *DB) PrepareEx(sqlOrTpl string, args any) (*sqlx.Stmt, error) {
 	if d == nil {
 		return nil, ErrNilDB
@@ -110,35 +124,93 @@ func (d *DB) RunPrepareNamed(sqlOrTpl string, arg any, fn func(*sqlx.NamedStmt)
 	}()
 	return fn(stmt)
 }
+
+// SelectEx 使用模版或SQL查询多条记录，模版声明的元数据(@timeout、@ds、@result)参见TplMeta
 func (d *DB) SelectEx(dest interface{}, sqlOrTpl string, args ...any) error {
 	if d == nil {
 		return ErrNilDB
 	}
-	query, err := d.ParseSQL(sqlOrTpl, args)
+	db, ctx, cancel, err := d.execContext(sqlOrTpl, false)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	query, bindArgs, err := db.ParseSQLArgs(sqlOrTpl, args)
 	if err != nil {
 		return err
 	}
+	args = execArgs(bindArgs, args)
 	log.Debug("select:", query, args)
-	return d.DB.Select(dest, query, args...)
+	return db.DB.SelectContext(ctx, dest, query, args...)
+}
+
+// GetEx 使用模版或SQL查询单条记录
+func (d *DB) GetEx(dest interface{}, sqlOrTpl string, args ...any) error {
+	if d == nil {
+		return ErrNilDB
+	}
+	db, ctx, cancel, err := d.execContext(sqlOrTpl, false)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	query, bindArgs, err := db.ParseSQLArgs(sqlOrTpl, args)
+	if err != nil {
+		return err
+	}
+	args = execArgs(bindArgs, args)
+	log.Debug("get:", query, args)
+	return db.DB.GetContext(ctx, dest, query, args...)
 }
 func (d *DB) NamedSelectEx(dest interface{}, sqlOrTpl string, args interface{}) (err error) {
 	if d == nil {
 		return ErrNilDB
 	}
+	db, ctx, cancel, err := d.execContext(sqlOrTpl, false)
+	if err != nil {
+		return err
+	}
+	defer cancel()
 	var named *sqlx.NamedStmt
-	named, err = d.PrepareNamedEx(sqlOrTpl, args)
+	named, err = db.PrepareNamedEx(sqlOrTpl, args)
 	if err != nil {
 		return err
 	}
 	defer func(named *sqlx.NamedStmt) {
-		err = named.Close()
-
+		if closeErr := named.Close(); err == nil {
+			err = closeErr
+		}
 	}(named)
 	if args == nil {
 		args = map[string]any{}
 	}
 	log.Debug("named select tpl:", named.QueryString, args)
-	return named.Select(dest, args)
+	return named.SelectContext(ctx, dest, args)
+}
+
+// NamedGetEx 使用模版或SQL查询单条记录,使用命名参数
+func (d *DB) NamedGetEx(dest interface{}, sqlOrTpl string, arg interface{}) (err error) {
+	if d == nil {
+		return ErrNilDB
+	}
+	db, ctx, cancel, err := d.execContext(sqlOrTpl, false)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	var named *sqlx.NamedStmt
+	named, err = db.PrepareNamedEx(sqlOrTpl, arg)
+	if err != nil {
+		return err
+	}
+	defer func(named *sqlx.NamedStmt) {
+		//保留查询的错误(例如sql.ErrNoRows)，查询成功时才返回关闭语句的错误
+		if closeErr := named.Close(); err == nil {
+			err = closeErr
+		}
+	}(named)
+	log.Debug("named get tpl:", named.QueryString, arg)
+	return named.GetContext(ctx, dest, arg)
 }
 func (d *DB) NamedSelect(dest interface{}, sql string, arg any) (err error) {
 	if d == nil {
@@ -160,44 +232,89 @@ func (d *DB) NamedExecEx(sqlOrTpl string, arg interface{}) (sql.Result, error) {
 	if d == nil {
 		return nil, ErrNilDB
 	}
-	query, err := d.ParseSQL(sqlOrTpl, arg)
+	db, ctx, cancel, err := d.execContext(sqlOrTpl, true)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	query, err := db.ParseSQL(sqlOrTpl, arg)
 	if err != nil {
 		return nil, err
 	}
 	log.Debug("named exec:", query, arg)
-	return d.NamedExec(query, arg)
+	return db.NamedExecContext(ctx, query, arg)
 }
 
 func (d *DB) ExecEx(sqlOrTpl string, args ...interface{}) (sql.Result, error) {
 	if d == nil {
 		return nil, ErrNilDB
 	}
-	query, err := d.ParseSQL(sqlOrTpl, args)
+	db, ctx, cancel, err := d.execContext(sqlOrTpl, true)
 	if err != nil {
 		return nil, err
 	}
+	defer cancel()
+	query, bindArgs, err := db.ParseSQLArgs(sqlOrTpl, args)
+	if err != nil {
+		return nil, err
+	}
+	args = execArgs(bindArgs, args)
 	log.Debug("exec:", query, args)
-	return d.Exec(query, args...)
+	return db.ExecContext(ctx, query, args...)
 }
+
+// NamedQueryEx 使用模版或SQL查询，返回的结果集由调用者关闭，因此只使用模版声明的数据源(@ds)，不使用超时
 func (d *DB) NamedQueryEx(sqlOrTpl string, arg interface{}) (*sqlx.Rows, error) {
 	if d == nil {
 		return nil, ErrNilDB
 	}
-	query, err := d.ParseSQL(sqlOrTpl, arg)
+	db := d
+	if meta := d.metaOf(sqlOrTpl); meta != nil && meta.DS != "" && d.m != nil {
+		var err error
+		if db, err = d.m.Get(meta.DS); err != nil {
+			return nil, err
+		}
+	}
+	query, err := db.ParseSQL(sqlOrTpl, arg)
 	if err != nil {
 		return nil, err
 	}
 	log.Debug("named query:", query, arg)
-	return d.NamedQuery(query, arg)
+	return db.NamedQuery(query, arg)
 }
 func (d *DB) Batch(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) (err error) {
 	return d.BatchEx(ctx, opts, "", fn)
 }
 
+// BatchEx 在事务中执行fn，tpl为事务中使用的模版(Tx.ExecCurrent等)，
+// 模版声明了@readonly时使用只读事务，声明了@timeout时作为整个事务的超时时间，声明了@ds时在对应的数据源中执行
 func (d *DB) BatchEx(ctx context.Context, opts *sql.TxOptions, tpl string, fn func(tx *Tx) error) (err error) {
 	if d == nil {
 		return ErrNilDB
 	}
+	if meta := d.metaOf(tpl); meta != nil {
+		if meta.DS != "" && d.m != nil {
+			var db *DB
+			if db, err = d.m.Get(meta.DS); err != nil {
+				return err
+			}
+			if db != d {
+				return db.BatchEx(ctx, opts, tpl, fn)
+			}
+		}
+		if meta.Readonly {
+			txOpts := sql.TxOptions{ReadOnly: true}
+			if opts != nil {
+				txOpts.Isolation = opts.Isolation
+			}
+			opts = &txOpts
+		}
+		if meta.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, meta.Timeout)
+			defer cancel()
+		}
+	}
 	var tx *sqlx.Tx
 	tx, err = d.BeginTxx(ctx, opts)
 	if err != nil {
@@ -298,16 +415,26 @@ func (d *DB) NamedGet(dest interface{}, query string, arg interface{}) error {
 func (d *DB) SetTemplate(tpl *template.Template) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
-	d.template = tpl
+	d.template.Store(tpl)
+	d.tplVersion.Add(1)
 }
 func (d *DB) Template() *template.Template {
-	return d.template
+	return d.template.Load()
 }
 
 // ParseTemplateFS parse template from filesystem。
 // 为了保留目录结构，没有直接使用template的ParseFS(template中的ParseFS方法不会保留路径名称)
+//
+// 解析在模版集合的副本上进行，全部解析成功后原子替换当前的模版集合，解析失败时当前的模版集合保持不变
 func (d *DB) ParseTemplateFS(f fs.FS, patterns ...string) error {
 	log.Info("parse template from filesystem: ", f, " with patterns:", patterns)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	tpl, err := d.Template().Clone()
+	if err != nil {
+		return err
+	}
+	metas := map[string]*TplMeta{}
 	for _, pattern := range patterns {
 		matches, err := fs.Glob(f, pattern)
 		if err != nil {
@@ -319,11 +446,17 @@ func (d *DB) ParseTemplateFS(f fs.FS, patterns ...string) error {
 				return err
 			}
 			log.Info("parse sql:", mf)
-			if _, err = d.template.New(strings.ReplaceAll(mf, "\\", "/")).Parse(string(buf)); err != nil {
+			meta, err := parseTplMeta(string(buf))
+			if err != nil {
+				return fmt.Errorf("%s: %w", mf, err)
+			}
+			if _, err = tpl.New(templateName(mf)).Parse(string(buf)); err != nil {
 				return err
 			}
+			metas[templateName(mf)] = meta
 		}
 	}
+	d.storeTemplate(tpl, metas)
 	return nil
 }
 func (d *DB) MustParseTemplateFS(f fs.FS, patterns ...string) *DB {
@@ -335,26 +468,98 @@ func (d *DB) MustParseTemplateFS(f fs.FS, patterns ...string) *DB {
 }
 
 // ParseTemplate parse template from string
+// 解析在模版集合的副本上进行，成功后原子替换当前的模版集合
 func (d *DB) ParseTemplate(name string, tpl string) (*template.Template, error) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
-	t, err := d.template.New(name).Parse(tpl)
-	return t, err
+	meta, err := parseTplMeta(tpl)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	clone, err := d.Template().Clone()
+	if err != nil {
+		return nil, err
+	}
+	t, err := clone.New(name).Parse(tpl)
+	if err != nil {
+		return nil, err
+	}
+	d.storeTemplate(clone, map[string]*TplMeta{name: meta})
+	return t, nil
+}
+
+// storeTemplate 原子替换模版集合并更新模版的元数据，调用者需要持有d.lock
+func (d *DB) storeTemplate(tpl *template.Template, metas map[string]*TplMeta) {
+	d.template.Store(tpl)
+	d.tplVersion.Add(1)
+	for name, meta := range metas {
+		d.setTemplateMeta(name, meta)
+	}
+}
+
+// dialectTemplateName 方言模版的名称，例如：get_user.sql => get_user.postgres.sql
+func dialectTemplateName(name string, driver *dialect.Dialect) string {
+	return strings.TrimSuffix(name, sqlSuffix) + "." + driver.Name + sqlSuffix
+}
+
+// LookupTemplate 查找模版，优先使用当前方言的模版(例如：get_user.postgres.sql)，不存在时使用通用模版(get_user.sql)，
+// 都不存在时返回空字符串
+func (d *DB) LookupTemplate(name string) string {
+	tpl := d.Template()
+	if d.driver != nil {
+		if variant := dialectTemplateName(name, d.driver); tpl.Lookup(variant) != nil {
+			return variant
+		}
+	}
+	if tpl.Lookup(name) != nil {
+		return name
+	}
+	return ""
 }
 
 // ParseSQL parse sql from template
 // 2023-7-12: 由于template的Parse方法会将{{}}中的内容当作变量，所以不再使用template.Parse方法,由BoostMapper中预先解析，减小运行时性能消耗和锁定
+//
+// 存在当前方言的模版时优先使用方言模版，参见LookupTemplate；模版使用了bind时返回ErrBindArgs，请使用ParseSQLArgs
 func (d *DB) ParseSQL(sqlOrTpl string, args any) (query string, err error) {
+	var bindArgs []any
+	if query, bindArgs, err = d.ParseSQLArgs(sqlOrTpl, args); err == nil && bindArgs != nil {
+		return "", fmt.Errorf("%w: %s", ErrBindArgs, sqlOrTpl)
+	}
+	return
+}
 
+// ParseSQLArgs 和ParseSQL一样解析模版，同时返回模版中bind绑定的参数
+//
+// 模版中使用bind函数时输出当前方言的占位符，bindArgs按顺序返回绑定的参数，执行时应使用bindArgs代替调用参数；
+// 没有使用bind时bindArgs为nil。bind只适用于位置参数，不能与命名参数(:name)混用
+func (d *DB) ParseSQLArgs(sqlOrTpl string, args any) (query string, bindArgs []any, err error) {
 	if !strings.HasSuffix(sqlOrTpl, sqlSuffix) {
-		return sqlOrTpl, nil
+		return sqlOrTpl, nil, nil
+	}
+	if name := d.LookupTemplate(sqlOrTpl); name != "" {
+		sqlOrTpl = name
+	}
+	var bound *boundTemplate
+	if bound, err = d.boundTemplate(); err != nil {
+		return
 	}
 	sb := &strings.Builder{}
-	err = d.template.ExecuteTemplate(sb, sqlOrTpl, args)
+	err = bound.tpl.ExecuteTemplate(sb, sqlOrTpl, args)
 	if err == nil {
 		query = sb.String()
+		bindArgs = bound.binder.args
 	}
-	//}
-	log.Trace("parse sql:", sqlOrTpl, "=>", query, " with args:", args)
+	bound.binder.args = nil
+	d.bindPool.Put(bound)
+	log.Trace("parse sql:", sqlOrTpl, "=>", query, " with args:", args, " bind args:", bindArgs)
 	return
 }
+
+// execArgs 模版使用了bind时使用绑定的参数，否则使用调用参数
+func execArgs(bindArgs []any, args []any) []any {
+	if bindArgs != nil {
+		return bindArgs
+	}
+	return args
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gnodux/sqlmx/builtin"
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedGetExNoRows(t *testing.T) {
+	d := newFakeDB(t, dialect.MySQL, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, nil, nil
+	})
+	_, err := d.ParseTemplate("user/get_user.sql", "SELECT `id` FROM `user` WHERE `id`=:id")
+	assert.NoError(t, err)
+	var id int
+	err = d.NamedGetEx(&id, "user/get_user.sql", map[string]any{"id": 1})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	m := NewDBManager("fake")
+	m.Set("db", d)
+	type userMapper struct {
+		GetUser NamedGetFunc[int] `sql:"user/get_user.sql"`
+	}
+	mapper, err := NewMapperWith[userMapper](m, "db")
+	assert.NoError(t, err)
+	_, err = mapper.GetUser(map[string]any{"id": 1})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+type account struct {
+	ID    int64
+	Email string `dbx:"unique"`
+	Name  string
+}
+
+type device struct {
+	ID   string `dbx:"primaryKey"`
+	Name string
+}
+
+func TestInsertReturningBackfill(t *testing.T) {
+	var queries []string
+	d := newFakeDB(t, dialect.Postgres, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		queries = append(queries, query)
+		if strings.Contains(query, "account") {
+			//返回行的顺序和插入的顺序不一致
+			return []string{"id", "email"}, [][]driver.Value{{int64(2), "b@x"}, {int64(1), "a@x"}}, nil
+		}
+		//RETURNING按照VALUES的顺序返回
+		var rows [][]driver.Value
+		for idx := range args {
+			rows = append(rows, []driver.Value{fmt.Sprintf("uuid-%d", idx+1)})
+		}
+		return []string{"id"}, rows, nil
+	})
+	m := NewDBManager("fake")
+	m.Set("db", d)
+
+	accounts, err := NewMapperWith[BaseMapper[*account]](m, "db")
+	assert.NoError(t, err)
+	a, b := &account{Email: "a@x", Name: "a"}, &account{Email: "b@x", Name: "b"}
+	assert.NoError(t, accounts.Insert(a, b))
+	assert.Equal(t, int64(1), a.ID)
+	assert.Equal(t, int64(2), b.ID)
+	assert.Len(t, queries, 1)
+	assert.Contains(t, queries[0], `RETURNING "id","email"`)
+
+	//没有唯一键时按RETURNING的顺序回填
+	queries = nil
+	devices, err := NewMapperWith[BaseMapper[device]](m, "db")
+	assert.NoError(t, err)
+	list := []device{{Name: "a"}, {Name: "b"}}
+	assert.NoError(t, devices.Insert(list...))
+	assert.Len(t, queries, 1)
+	assert.Equal(t, "uuid-1", list[0].ID)
+	assert.Equal(t, "uuid-2", list[1].ID)
+}
+
+func TestInsertSelectPrimaryKeys(t *testing.T) {
+	var queries []string
+	d := newFakeDB(t, dialect.MySQL, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		queries = append(queries, query)
+		if strings.HasPrefix(query, "SELECT") {
+			return []string{"id", "email"}, [][]driver.Value{{int64(7), "b@x"}, {int64(3), "a@x"}}, nil
+		}
+		return nil, nil, nil
+	})
+	m := NewDBManager("fake")
+	m.Set("db", d)
+	accounts, err := NewMapperWith[BaseMapper[*account]](m, "db")
+	assert.NoError(t, err)
+	a, b := &account{Email: "a@x", Name: "a"}, &account{Email: "b@x", Name: "b"}
+	assert.NoError(t, accounts.Insert(a, b))
+	assert.Equal(t, int64(3), a.ID)
+	assert.Equal(t, int64(7), b.ID)
+	if assert.Len(t, queries, 2) {
+		assert.Equal(t, "SELECT `id`,`email` FROM `account` WHERE ( `email` = ? ) OR ( `email` = ? )", queries[1])
+	}
+}
+
+func TestInsertStatementCount(t *testing.T) {
+	type note struct {
+		ID   int64
+		Text string
+	}
+	const total = 1200
+	tests := []struct {
+		name       string
+		dialect    *dialect.Dialect
+		setup      func(m *BaseMapper[note])
+		statements int
+		backfill   bool
+	}{
+		{name: "postgres returning", dialect: dialect.Postgres, statements: 3, backfill: true},
+		{name: "sql server output", dialect: dialect.SQLServer, statements: 3},
+		{name: "mysql", dialect: dialect.MySQL, statements: 3},
+		{name: "mysql consecutive ids", dialect: dialect.MySQL, setup: func(m *BaseMapper[note]) { m.ConsecutiveIds = true }, statements: 3, backfill: true},
+		{name: "mysql per row", dialect: dialect.MySQL, setup: func(m *BaseMapper[note]) { m.InsertPerRow = true }, statements: total, backfill: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := 0
+			d := newFakeDB(t, tt.dialect, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+				statements++
+				var rows [][]driver.Value
+				if strings.Contains(query, "RETURNING") {
+					for idx := range args {
+						rows = append(rows, []driver.Value{int64(idx + 1)})
+					}
+				}
+				return []string{"id"}, rows, nil
+			})
+			m := NewDBManager("fake")
+			m.Set("db", d)
+			notes, err := NewMapperWith[BaseMapper[note]](m, "db")
+			assert.NoError(t, err)
+			if tt.setup != nil {
+				tt.setup(notes)
+			}
+			list := make([]note, total)
+			for idx := range list {
+				list[idx].Text = fmt.Sprintf("note %d", idx)
+			}
+			assert.NoError(t, notes.Insert(list...))
+			assert.Equal(t, tt.statements, statements)
+			assert.Equal(t, tt.backfill, list[total-1].ID != 0)
+		})
+	}
+}
+
+func TestCreateReturningKeyType(t *testing.T) {
+	var queries []string
+	d := newFakeDB(t, dialect.Postgres, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		queries = append(queries, query)
+		return []string{"id"}, [][]driver.Value{{fmt.Sprintf("uuid-%d", len(queries))}}, nil
+	})
+	assert.NoError(t, d.ParseTemplateFS(builtin.Builtin, "builtin/*.sql"))
+	m := NewDBManager("fake")
+	m.Set("db", d)
+	devices, err := NewMapperWith[BaseMapper[*device]](m, "db")
+	assert.NoError(t, err)
+	a, b := &device{Name: "a"}, &device{Name: "b"}
+	assert.NoError(t, devices.Create(a, b))
+	assert.Equal(t, "uuid-1", a.ID)
+	assert.Equal(t, "uuid-2", b.ID)
+	if assert.Len(t, queries, 2) {
+		assert.Contains(t, queries[0], `RETURNING "id"`)
+	}
+}
+
+func TestUpsertColumnOrder(t *testing.T) {
+	var queries []string
+	d := newFakeDB(t, dialect.MySQL, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		queries = append(queries, query)
+		return nil, nil, nil
+	})
+	assert.NoError(t, d.ParseTemplateFS(builtin.Builtin, "builtin/*.sql"))
+	m := NewDBManager("fake")
+	m.Set("db", d)
+	accounts, err := NewMapperWith[BaseMapper[*account]](m, "db")
+	assert.NoError(t, err)
+	for idx := 0; idx < 10; idx++ {
+		assert.NoError(t, accounts.Upsert(&account{ID: 1, Email: "a@b.c", Name: "a"}))
+	}
+	assert.Len(t, queries, 10)
+	for _, query := range queries {
+		assert.Equal(t, "INSERT INTO `account` ( `id`,`email`,`name` ) VALUES ( ?,?,? ) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)", query)
+	}
+}
+
+func TestUpsertMergeWithoutConflictColumns(t *testing.T) {
+	d := newFakeDB(t, dialect.SQLServer, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	assert.NoError(t, d.ParseTemplateFS(builtin.Builtin, "builtin/*.sql"))
+	m := NewDBManager("fake")
+	m.Set("db", d)
+	accounts, err := NewMapperWith[BaseMapper[*account]](m, "db")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, accounts.Upsert(&account{Name: "a"}), expr.ErrNoConflictColumns)
+	assert.NoError(t, accounts.Upsert(&account{Email: "a@b.c", Name: "a"}))
+}
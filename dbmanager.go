@@ -46,6 +46,10 @@ var (
 	SetDialect = Manager.SetDefaultDialect
 	//ClearTemplateFS clear sql template from filesystem
 	ClearTemplateFS = Manager.ClearTemplateFS
+	//WatchTemplateFS reload changed sql templates periodically(development mode)
+	WatchTemplateFS = Manager.WatchTemplateFS
+	//SetStrictMapper validate mappers when boost
+	SetStrictMapper = Manager.SetStrictMapper
 
 	//Shutdown manager and close all db
 	Shutdown = Manager.Shutdown
@@ -74,6 +78,11 @@ type DBManager struct {
 	constructors map[string]ConnFunc
 	lock         *sync.RWMutex
 	templateFS   []*TplFS
+	//tplFiles 热加载时记录的模版文件状态
+	tplFiles   map[string]tplFile
+	reloadLock sync.Mutex
+	//strict 严格模式，BoostMapper时检查mapper的绑定结果
+	strict bool
 }
 
 func NewManagerWithDriver(name string, driver *dialect.Dialect) *DBManager {
@@ -100,6 +109,12 @@ func (m *DBManager) ClearTemplateFS() {
 	m.templateFS = nil
 }
 
+// SetStrictMapper 设置严格模式，开启后BoostMapper/NewMapper检查每个函数字段是否能够识别、模版是否存在、
+// inline SQL是否能够解析、命名参数是否存在于参数类型中，所有问题合并为一个错误返回
+func (m *DBManager) SetStrictMapper(strict bool) {
+	m.strict = strict
+}
+
 //Get 获取一个数据库连接
 //name: 数据库连接名称
 
@@ -227,7 +242,8 @@ func (m *DBManager) OpenWith(curDialect *dialect.Dialect, datasource string) (*D
 	}
 	db.MapperFunc(NameFunc)
 
-	newDb := &DB{DB: db, m: m, driver: curDialect, template: template.New("sql").Funcs(MakeFuncMap(curDialect))}
+	newDb := &DB{DB: db, m: m, driver: curDialect}
+	newDb.SetTemplate(template.New("sql").Funcs(MakeFuncMap(curDialect)))
 	err = newDb.ParseTemplateFS(builtin.Builtin, "builtin/*.sql")
 	if err != nil {
 		return nil, err
@@ -5,6 +5,39 @@
 
 package dialect
 
+import (
+	"github.com/gnodux/sqlmx/utils"
+	"strconv"
+	"strings"
+)
+
+const (
+	//UpsertOnDuplicateKey INSERT ... ON DUPLICATE KEY UPDATE(MySQL)
+	UpsertOnDuplicateKey = "ON DUPLICATE KEY UPDATE"
+	//UpsertOnConflict INSERT ... ON CONFLICT DO UPDATE/DO NOTHING(Postgres)
+	UpsertOnConflict = "ON CONFLICT"
+	//UpsertMerge MERGE INTO ... USING ...(SQLServer)
+	UpsertMerge = "MERGE"
+
+	//ReturningClause 语句末尾使用RETURNING返回数据(Postgres)
+	ReturningClause = "RETURNING"
+	//ReturningOutput 使用OUTPUT INSERTED/DELETED返回数据(SQLServer)
+	ReturningOutput = "OUTPUT"
+)
+
+// 通用的数据类型名称，CAST时通过Dialect.TypeName转换为方言中的类型
+const (
+	TypeInt      = "INT"
+	TypeBigInt   = "BIGINT"
+	TypeString   = "STRING"
+	TypeBool     = "BOOLEAN"
+	TypeFloat    = "FLOAT"
+	TypeDecimal  = "DECIMAL"
+	TypeDate     = "DATE"
+	TypeTime     = "TIME"
+	TypeDateTime = "DATETIME"
+)
+
 type Dialect struct {
 	//驱动名称（mysql/mssql）等
 	Name string
@@ -14,6 +47,8 @@ type Dialect struct {
 	NamedPrefix string
 	//参数占位符
 	PlaceHolder string
+	//NumberedPlaceHolder 参数占位符是否需要编号，例如Postgres的$1,$2
+	NumberedPlaceHolder bool
 	//SQLNameFunc SQL名称转换函数
 	SQLNameFunc func(any) string
 	//NameFunc 字段名称转换函数
@@ -22,6 +57,34 @@ type Dialect struct {
 	DateFormat string
 	//Keywords 关键字映射
 	Keywords map[string]string
+	//SupportJoinUsing 是否支持JOIN ... USING(...)语法，不支持时转换为ON条件
+	SupportJoinUsing bool
+	//PaginationFunc 分页语句生成函数，为空时使用LimitOffset
+	PaginationFunc func(limit, offset func() string) string
+	//DefaultOrderBy 分页时没有ORDER BY所使用的默认排序(SQLServer的OFFSET FETCH必须有ORDER BY)
+	DefaultOrderBy string
+	//Upsert upsert语法(UpsertOnDuplicateKey/UpsertOnConflict/UpsertMerge)，为空时使用UpsertOnConflict
+	Upsert string
+	//MaxParams 单条语句允许的最大参数数量，0表示不限制
+	MaxParams int
+	//MaxInsertRows 单条INSERT ... VALUES允许的最大行数，0表示不限制
+	MaxInsertRows int
+	//Returning 返回修改数据的语法(ReturningClause/ReturningOutput)，为空时表示不支持
+	Returning string
+	//TypeNames 通用数据类型名称到方言类型名称的映射(用于CAST)
+	TypeNames map[string]string
+	//NullSafeEqual NULL安全的等于运算符(MySQL的<=>)，为空时使用IS [NOT] DISTINCT FROM
+	NullSafeEqual string
+	//LockHint 使用表提示实现行锁，例如SQLServer的WITH (UPDLOCK, ROWLOCK)，否则使用FOR UPDATE/FOR SHARE
+	LockHint bool
+	//BackslashEscape 字符串中的反斜杠是否为转义字符(MySQL)，否则仅使用两个连续的单引号转义单引号
+	BackslashEscape bool
+	//DoubleQuoteString 双引号是否表示字符串(MySQL的默认模式)，否则表示引用的标识符
+	DoubleQuoteString bool
+	//TypedLiteral 是否支持DATE '2006-01-02'形式的日期字面量，不支持时使用CAST('2006-01-02' AS DATE)
+	TypedLiteral bool
+	//PipesConcat ||是否为字符串连接运算符(Postgres)，否则使用CONCAT函数连接字符串(MySQL中||为逻辑或)
+	PipesConcat bool
 }
 
 func (d *Dialect) Keyword(name string) string {
@@ -33,6 +96,14 @@ func (d *Dialect) Keyword(name string) string {
 	}
 	return name
 }
+
+// BindVar 返回第idx(从1开始)个位置参数的占位符
+func (d *Dialect) BindVar(idx int) string {
+	if d.NumberedPlaceHolder {
+		return d.PlaceHolder + strconv.Itoa(idx)
+	}
+	return d.PlaceHolder
+}
 func (d *Dialect) KeywordWith(prefix string, kw string, suffix string) string {
 
 	return prefix + d.Keyword(kw) + suffix
@@ -40,3 +111,74 @@ func (d *Dialect) KeywordWith(prefix string, kw string, suffix string) string {
 func (d *Dialect) KeywordWithSpace(kw string) string {
 	return d.KeywordWith(" ", kw, " ")
 }
+
+// Pagination 生成分页语句
+// limit和offset函数返回格式化后的参数(占位符或命名参数)，调用顺序即参数的绑定顺序
+func (d *Dialect) Pagination(limit, offset func() string) string {
+	if d.PaginationFunc == nil {
+		return LimitOffset(limit, offset)
+	}
+	return d.PaginationFunc(limit, offset)
+}
+
+// TypeName 转换数据类型名称，支持带参数的类型，例如：STRING(50)，未定义映射时返回原名称
+func (d *Dialect) TypeName(name string) string {
+	if d.TypeNames == nil {
+		return name
+	}
+	if t, ok := d.TypeNames[name]; ok {
+		return t
+	}
+	if idx := strings.Index(name, "("); idx > 0 {
+		if t, ok := d.TypeNames[name[:idx]]; ok {
+			//使用指定的参数替换映射类型的默认参数，例如：STRING(50) => NVARCHAR(50)
+			if pos := strings.Index(t, "("); pos > 0 {
+				t = t[:pos]
+			}
+			return t + name[idx:]
+		}
+	}
+	return name
+}
+
+// QuoteString 将字符串转换为方言中的字符串字面量
+func (d *Dialect) QuoteString(s string) string {
+	if d.BackslashEscape {
+		return "'" + utils.Escape(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// SupportReturning 是否支持在INSERT/UPDATE/DELETE中返回修改的数据
+func (d *Dialect) SupportReturning() bool {
+	return d.Returning != ""
+}
+
+// InsertChunkSize 计算多行插入时每条语句的行数，size为期望的行数，columns为每行的参数数量
+// 返回值不会超过方言的参数数量限制和行数限制
+func (d *Dialect) InsertChunkSize(columns int, size int) int {
+	if d.MaxParams > 0 && columns > 0 && size*columns > d.MaxParams {
+		size = d.MaxParams / columns
+	}
+	if d.MaxInsertRows > 0 && size > d.MaxInsertRows {
+		size = d.MaxInsertRows
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// LimitOffset LIMIT ... OFFSET ...分页(MySQL、Postgres)
+func LimitOffset(limit, offset func() string) string {
+	l := limit()
+	o := offset()
+	return "LIMIT " + l + " OFFSET " + o
+}
+
+// OffsetFetch OFFSET ... ROWS FETCH NEXT ... ROWS ONLY分页(SQLServer)
+func OffsetFetch(limit, offset func() string) string {
+	o := offset()
+	l := limit()
+	return "OFFSET " + o + " ROWS FETCH NEXT " + l + " ROWS ONLY"
+}
@@ -20,6 +20,23 @@ var (
 		SQLNameFunc:  MakeNameFunc("`", "`"),
 		NameFunc:     utils.LowerCase,
 		PlaceHolder:  "?",
+
+		SupportJoinUsing: true,
+		Upsert:           UpsertOnDuplicateKey,
+		MaxParams:        65535,
+		NullSafeEqual:    "<=>",
+		BackslashEscape:  true,
+		//双引号在MySQL的默认模式(未启用ANSI_QUOTES)下表示字符串
+		DoubleQuoteString: true,
+		TypedLiteral:      true,
+		//MySQL的CAST仅支持有限的目标类型
+		TypeNames: map[string]string{
+			TypeInt:    "SIGNED",
+			TypeBigInt: "SIGNED",
+			TypeString: "CHAR",
+			TypeBool:   "UNSIGNED",
+			TypeFloat:  "DOUBLE",
+		},
 	}
 
 	//SQLServer SQLServer驱动
@@ -31,16 +48,50 @@ var (
 		DateFormat:   "'2006-01-02 15:04:05'",
 		SQLNameFunc:  MakeNameFunc("[", "]"),
 		NameFunc:     utils.LowerCase,
+		Keywords: map[string]string{
+			//SQLServer 的递归CTE不需要RECURSIVE关键字
+			"WITH RECURSIVE": "WITH",
+			//SQLServer 没有布尔字面量，使用BIT值
+			"TRUE":  "1",
+			"FALSE": "0",
+		},
+		PaginationFunc: OffsetFetch,
+		DefaultOrderBy: "(SELECT NULL)",
+		Upsert:         UpsertMerge,
+		MaxParams:      2100,
+		MaxInsertRows:  1000,
+		Returning:      ReturningOutput,
+		LockHint:       true,
+		TypeNames: map[string]string{
+			TypeString:   "NVARCHAR(MAX)",
+			TypeBool:     "BIT",
+			TypeDateTime: "DATETIME2",
+		},
 	}
 	// Postgres 驱动
 	Postgres = &Dialect{
 		Name:         "postgres",
 		SupportNamed: true,
-		NamedPrefix:  "$",
+		NamedPrefix:  ":", //命名参数使用sqlx的格式，执行时由sqlx转换为$1,$2
 		PlaceHolder:  "$",
 		DateFormat:   "'2006-01-02 15:04:05'",
 		SQLNameFunc:  MakeNameFunc("\"", "\""),
 		NameFunc:     utils.LowerCase,
+
+		NumberedPlaceHolder: true,
+		SupportJoinUsing:    true,
+		Upsert:              UpsertOnConflict,
+		MaxParams:           65535,
+		Returning:           ReturningClause,
+		TypedLiteral:        true,
+		PipesConcat:         true,
+		TypeNames: map[string]string{
+			TypeInt:      "INTEGER",
+			TypeString:   "VARCHAR",
+			TypeFloat:    "DOUBLE PRECISION",
+			TypeDecimal:  "NUMERIC",
+			TypeDateTime: "TIMESTAMP",
+		},
 	}
 )
 
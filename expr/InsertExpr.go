@@ -6,13 +6,39 @@
 package expr
 
 import (
+	"errors"
+	"fmt"
+	"github.com/gnodux/sqlmx/dialect"
 	"github.com/gnodux/sqlmx/expr/keywords"
 )
 
+// ErrNoConflictColumns MERGE的插入列中没有任何冲突检测列，无法匹配已有的行
+var ErrNoConflictColumns = errors.New("no conflict columns in inserted columns")
+
+const (
+	mergeTarget = "target"
+	mergeSource = "source"
+)
+
 // InsertExpr is a struct for insert expression
 type InsertExpr struct {
 	Table      Expr
 	ValueExprs []*BinaryExpr
+	//ColumnExprs 多行插入或INSERT ... SELECT使用的列，设置后忽略ValueExprs
+	ColumnExprs []Expr
+	//RowExprs 多行插入的值，每行的值与ColumnExprs的顺序一致
+	RowExprs [][]Expr
+	//QueryExpr INSERT ... SELECT使用的查询
+	QueryExpr Expr
+	//ConflictColumns 冲突检测列(upsert)，MySQL使用表上的主键和唯一索引，忽略该设置
+	ConflictColumns []Expr
+	//UpdateExprs 冲突时的更新赋值(upsert)，为空时冲突不做任何处理
+	UpdateExprs []*BinaryExpr
+	//ReturningExprs 插入后返回的列
+	ReturningExprs []Expr
+	//IdentityColumns 自增列，MERGE(SQLServer)的WHEN NOT MATCHED分支不插入这些列
+	IdentityColumns []Expr
+	upsert          bool
 }
 
 // Into is a function to set table
@@ -40,23 +66,282 @@ func (i *InsertExpr) SetMap(data map[string]any) *InsertExpr {
 	return i
 }
 
+// Columns 设置多行插入或INSERT ... SELECT使用的列
+func (i *InsertExpr) Columns(cols ...Expr) *InsertExpr {
+	i.ColumnExprs = cols
+	return i
+}
+
+// Row 添加一行数据，值的顺序与Columns一致
+func (i *InsertExpr) Row(values ...Expr) *InsertExpr {
+	i.RowExprs = append(i.RowExprs, values)
+	return i
+}
+
+// Select 使用查询结果插入数据(INSERT INTO ... SELECT ...)
+func (i *InsertExpr) Select(query Expr) *InsertExpr {
+	i.QueryExpr = query
+	return i
+}
+
+// RowCount 待插入的行数(INSERT ... SELECT时为0)
+func (i *InsertExpr) RowCount() int {
+	if i.QueryExpr != nil {
+		return 0
+	}
+	_, rows := i.columnsAndRows()
+	return len(rows)
+}
+
+// Split 按照每批size行拆分为多个InsertExpr，用于控制单条语句的参数数量
+func (i *InsertExpr) Split(size int) []*InsertExpr {
+	cols, rows := i.columnsAndRows()
+	if i.QueryExpr != nil || size <= 0 || len(rows) <= size {
+		return []*InsertExpr{i}
+	}
+	var chunks []*InsertExpr
+	for start := 0; start < len(rows); start += size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := *i
+		chunk.ValueExprs = nil
+		chunk.ColumnExprs = cols
+		chunk.RowExprs = rows[start:end]
+		chunks = append(chunks, &chunk)
+	}
+	return chunks
+}
+
+// OnConflict 使用upsert模式，并设置冲突检测列
+func (i *InsertExpr) OnConflict(cols ...Expr) *InsertExpr {
+	i.upsert = true
+	i.ConflictColumns = cols
+	return i
+}
+
+// DoUpdate 冲突时执行的更新赋值，例如：DoUpdate(N("name").Eq(Excluded(N("name"))))
+func (i *InsertExpr) DoUpdate(assignments ...*BinaryExpr) *InsertExpr {
+	i.upsert = true
+	i.UpdateExprs = append(i.UpdateExprs, assignments...)
+	return i
+}
+
+// DoUpdateExcluded 冲突时使用待插入的值更新指定列
+func (i *InsertExpr) DoUpdateExcluded(cols ...Expr) *InsertExpr {
+	for _, col := range cols {
+		i.DoUpdate(Binary(col, keywords.Equal, Excluded(col)))
+	}
+	return i
+}
+
+// Identity 设置自增列，SQLServer的自增列不能显式插入(IDENTITY_INSERT)，MERGE插入新行时忽略这些列
+func (i *InsertExpr) Identity(cols ...Expr) *InsertExpr {
+	i.IdentityColumns = cols
+	return i
+}
+
+// DoNothing 冲突时不做任何处理
+func (i *InsertExpr) DoNothing() *InsertExpr {
+	i.upsert = true
+	i.UpdateExprs = nil
+	return i
+}
+
+// IsUpsert 是否是upsert模式
+func (i *InsertExpr) IsUpsert() bool {
+	return i.upsert
+}
+
 func (i *InsertExpr) Format(buf *TracedBuffer) {
-	var cols []Expr
+	if i.upsert && buf.Upsert == dialect.UpsertMerge {
+		i.formatMerge(buf)
+		return
+	}
+	cols, rows := i.columnsAndRows()
+	buf.AppendKeyword(keywords.InsertInto)
+	buf.AppendString(" ")
+	i.Table.Format(buf)
+	if len(cols) > 0 {
+		buf.AppendString(" ")
+		Paren(List(keywords.Comma, cols...)).Format(buf)
+	}
+	formatOutput(buf, keywords.Inserted, i.ReturningExprs)
+	i.formatSource(buf, rows)
+	if i.upsert {
+		i.formatUpsert(buf, cols)
+	}
+	formatReturning(buf, i.ReturningExprs)
+}
+
+// formatSource 格式化插入的数据来源：VALUES ( ... ),( ... ) 或者 SELECT ...
+func (i *InsertExpr) formatSource(buf *TracedBuffer, rows [][]Expr) {
+	if i.QueryExpr != nil {
+		buf.AppendString(keywords.Space)
+		i.QueryExpr.Format(buf)
+		return
+	}
+	buf.AppendKeywordWithSpace(keywords.Values)
+	for idx, row := range rows {
+		if idx > 0 {
+			buf.AppendString(keywords.Comma)
+		}
+		Paren(List(keywords.Comma, row...)).Format(buf)
+	}
+}
+
+func (i *InsertExpr) columnsAndRows() (cols []Expr, rows [][]Expr) {
+	if len(i.ColumnExprs) > 0 {
+		return i.ColumnExprs, i.RowExprs
+	}
 	var values []Expr
 	for _, exp := range i.ValueExprs {
 		cols = append(cols, exp.Left)
 		values = append(values, exp.Right)
 	}
-	buf.AppendKeyword(keywords.InsertInto)
-	buf.AppendString(" ")
-	i.Table.Format(buf)
-	buf.AppendString(" ")
+	if len(values) > 0 {
+		rows = append(rows, values)
+	}
+	return
+}
+
+// formatUpsert 格式化INSERT之后的冲突处理子句
+func (i *InsertExpr) formatUpsert(buf *TracedBuffer, cols []Expr) {
+	switch buf.Upsert {
+	case dialect.UpsertOnDuplicateKey:
+		buf.AppendKeywordWithSpace(keywords.OnDuplicateKeyUpdate)
+		if len(i.UpdateExprs) > 0 {
+			formatAssignments(buf, i.UpdateExprs)
+		} else {
+			//MySQL没有DO NOTHING，使用无实际修改的赋值代替(不使用INSERT IGNORE，避免忽略其他错误)
+			if len(i.ConflictColumns) > 0 {
+				cols = i.ConflictColumns
+			}
+			if len(cols) > 0 {
+				Binary(cols[0], keywords.Equal, cols[0]).Format(buf)
+			}
+		}
+	default:
+		buf.AppendKeywordWithSpace(keywords.OnConflict)
+		if len(i.ConflictColumns) > 0 {
+			Paren(List(keywords.Comma, i.ConflictColumns...)).Format(buf)
+			buf.AppendString(keywords.Space)
+		}
+		if len(i.UpdateExprs) > 0 {
+			buf.AppendKeyword(keywords.DoUpdateSet).AppendString(keywords.Space)
+			formatAssignments(buf, i.UpdateExprs)
+		} else {
+			buf.AppendKeyword(keywords.DoNothing)
+		}
+	}
+}
+
+// formatMerge 使用MERGE语句实现upsert(SQLServer)
+func (i *InsertExpr) formatMerge(buf *TracedBuffer) {
+	cols, rows := i.columnsAndRows()
+	buf.AppendKeyword(keywords.MergeInto).AppendString(keywords.Space)
+	Alias(i.Table, mergeTarget).Format(buf)
+	buf.AppendKeywordWithSpace(keywords.Using)
+	buf.AppendString("(")
+	if i.QueryExpr != nil {
+		i.QueryExpr.Format(buf)
+	} else {
+		buf.AppendKeyword(keywords.Values).AppendString(keywords.Space)
+		for idx, row := range rows {
+			if idx > 0 {
+				buf.AppendString(keywords.Comma)
+			}
+			Paren(List(keywords.Comma, row...)).Format(buf)
+		}
+	}
+	buf.AppendString(")")
+	buf.AppendKeywordWithSpace(keywords.AS)
+	buf.AppendString(buf.SQLNameFunc(mergeSource)).AppendString(keywords.Space)
 	Paren(List(keywords.Comma, cols...)).Format(buf)
+	buf.AppendKeywordWithSpace(keywords.On)
+	//只使用插入列中存在的冲突检测列(例如新数据没有主键)，都不存在时返回错误
+	names := exprNames(cols)
+	var on []Expr
+	for _, col := range i.ConflictColumns {
+		name := exprName(col)
+		if names[name] {
+			on = append(on, Eq(Name(name, mergeTarget), Name(name, mergeSource)))
+		}
+	}
+	if len(on) > 0 {
+		And(on...).Format(buf)
+	} else {
+		buf.AddError(fmt.Errorf("%w: %s", ErrNoConflictColumns, buf.Capture(List(keywords.Comma, i.ConflictColumns...))))
+	}
+	if len(i.UpdateExprs) > 0 {
+		buf.AppendString(keywords.Space).AppendKeyword(keywords.WhenMatched).AppendString(keywords.Space)
+		formatAssignments(buf, i.UpdateExprs)
+	}
+	identity := exprNames(i.IdentityColumns)
+	var insertCols, sourceValues []Expr
+	for _, col := range cols {
+		if identity[exprName(col)] {
+			continue
+		}
+		insertCols = append(insertCols, col)
+		sourceValues = append(sourceValues, Name(exprName(col), mergeSource))
+	}
+	buf.AppendString(keywords.Space).AppendKeyword(keywords.WhenNotMatched).AppendString(keywords.Space)
+	Paren(List(keywords.Comma, insertCols...)).Format(buf)
 	buf.AppendKeywordWithSpace(keywords.Values)
-	Paren(List(keywords.Comma, values...)).Format(buf)
+	Paren(List(keywords.Comma, sourceValues...)).Format(buf)
+	formatOutput(buf, keywords.Inserted, i.ReturningExprs)
+	//MERGE语句必须以分号结束
+	buf.AppendString(";")
+}
+
+// exprNames 表达式的名称集合
+func exprNames(exps []Expr) map[string]bool {
+	names := make(map[string]bool, len(exps))
+	for _, exp := range exps {
+		names[exprName(exp)] = true
+	}
+	return names
+}
+
+func formatAssignments(buf *TracedBuffer, assignments []*BinaryExpr) {
+	for idx, assignment := range assignments {
+		if idx > 0 {
+			buf.AppendString(", ")
+		}
+		assignment.Format(buf)
+	}
+}
+
+// ExcludedExpr upsert时引用待插入的值，例如：MySQL: VALUES(`name`)，Postgres: EXCLUDED."name"，SQLServer: [source].[name]
+type ExcludedExpr struct {
+	Column Expr
+}
+
+func (e *ExcludedExpr) Format(buffer *TracedBuffer) {
+	switch buffer.Upsert {
+	case dialect.UpsertOnDuplicateKey:
+		Fn(keywords.Values, e.Column).Format(buffer)
+	case dialect.UpsertMerge:
+		Name(exprName(e.Column), mergeSource).Format(buffer)
+	default:
+		buffer.AppendKeyword(keywords.Excluded).AppendString(".")
+		e.Column.Format(buffer)
+	}
+}
+
+// Excluded 引用upsert中待插入的值
+func Excluded(col Expr) *ExcludedExpr {
+	return &ExcludedExpr{Column: col}
 }
 
 // InsertInto 创建一个InsertExpr并设置表名
 func InsertInto(table Expr, values ...*BinaryExpr) *InsertExpr {
 	return &InsertExpr{Table: table, ValueExprs: values}
 }
+
+// Upsert 创建一个upsert模式的InsertExpr，conflictColumns为冲突检测列
+func Upsert(table Expr, conflictColumns ...Expr) *InsertExpr {
+	return InsertInto(table).OnConflict(conflictColumns...)
+}
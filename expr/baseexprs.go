@@ -8,7 +8,7 @@ package expr
 import (
 	"fmt"
 	"github.com/gnodux/sqlmx/expr/keywords"
-	"github.com/gnodux/sqlmx/utils"
+	"strings"
 	"sync/atomic"
 	"time"
 )
@@ -91,11 +91,11 @@ func (c *ConstantExpr) Format(buffer *TracedBuffer) {
 			buffer.AppendString(buffer.Keyword("FALSE"))
 		}
 	case []byte:
-		buffer.AppendString("'").AppendString(utils.Escape(string(vv))).AppendString("'")
+		buffer.AppendString(buffer.QuoteString(string(vv)))
 	case string:
-		buffer.AppendString("'").AppendString(utils.Escape(vv)).AppendString("'")
+		buffer.AppendString(buffer.QuoteString(vv))
 	default:
-		buffer.AppendString("'").AppendString(fmt.Sprintf("%v", c.Value)).AppendString("'")
+		buffer.AppendString(buffer.QuoteString(fmt.Sprintf("%v", c.Value)))
 	}
 }
 
@@ -105,14 +105,15 @@ type NameExpr struct {
 }
 
 func (n *NameExpr) Format(buffer *TracedBuffer) {
-	if len(n.Qualifier) > 0 {
-		for _, qualifier := range n.Qualifier {
-			if len(qualifier) == 0 {
-				buffer.AppendString(buffer.SQLNameFunc(qualifier)).AppendString(".")
-			}
+	for _, qualifier := range n.Qualifier {
+		if len(qualifier) > 0 {
+			buffer.AppendString(buffer.SQLNameFunc(qualifier)).AppendString(".")
 		}
-		buffer.AppendString(buffer.SQLNameFunc(n.Qualifier))
-		buffer.AppendString(".")
+	}
+	//限定的所有列：`t`.*
+	if n.Name == keywords.All {
+		buffer.AppendString(keywords.All)
+		return
 	}
 	buffer.AppendString(buffer.SQLNameFunc(n.Name))
 }
@@ -145,6 +146,52 @@ func (n *NameExpr) In(values ...any) *BinaryExpr {
 func (n *NameExpr) NotIn(values ...any) *BinaryExpr {
 	return NotIn(n, n.Name, values...)
 }
+
+// As 设置别名：`name` AS `alias`，表名使用别名后可以通过AliasExpr.Col引用列
+func (n *NameExpr) As(alias string) *AliasExpr {
+	return Alias(n, alias)
+}
+
+// IsNull `name` IS NULL
+func (n *NameExpr) IsNull() *BinaryExpr {
+	return IsNull(n)
+}
+
+// IsNotNull `name` IS NOT NULL
+func (n *NameExpr) IsNotNull() *BinaryExpr {
+	return IsNotNull(n)
+}
+
+// IsDistinctFrom `name` IS DISTINCT FROM value
+func (n *NameExpr) IsDistinctFrom(value any) *DistinctExpr {
+	return IsDistinctFrom(n, value)
+}
+
+// IsNotDistinctFrom `name` IS NOT DISTINCT FROM value
+func (n *NameExpr) IsNotDistinctFrom(value any) *DistinctExpr {
+	return IsNotDistinctFrom(n, value)
+}
+
+// Coalesce COALESCE(`name`, values...)
+func (n *NameExpr) Coalesce(values ...any) *FuncExpr {
+	return Coalesce(append([]any{n}, values...)...)
+}
+
+// NullIf NULLIF(`name`, value)
+func (n *NameExpr) NullIf(value any) *FuncExpr {
+	return NullIf(n, value)
+}
+
+// Cast CAST(`name` AS typeName)
+func (n *NameExpr) Cast(typeName string) *CastExpr {
+	return Cast(n, typeName)
+}
+
+// Case 以当前列创建简单CASE表达式：CASE `name` WHEN ... END
+func (n *NameExpr) Case() *CaseExpr {
+	return SimpleCase(n)
+}
+
 func (n *NameExpr) Between(min, max any) *BetweenExpr {
 	minExp, minOk := min.(Expr)
 	maxExp, maxOk := max.(Expr)
@@ -190,12 +237,11 @@ type ValueExpr struct {
 // Format 格式化, 如果是命名参数, 则使用命名参数，否则使用占位符
 func (n *ValueExpr) Format(buffer *TracedBuffer) {
 	if buffer.NamedVar {
-		buffer.AppendNamedArg(n.Name, n.Value)
 		buffer.AppendString(buffer.NamedPrefix)
-		buffer.AppendString(n.Name)
+		buffer.AppendString(buffer.BindNamedArg(n.Name, n.Value))
 	} else {
 		buffer.AppendArg(n.Value)
-		buffer.AppendString(buffer.PlaceHolder)
+		buffer.AppendString(buffer.BindVar(len(buffer.args)))
 	}
 }
 
@@ -211,6 +257,22 @@ func (a *AliasExpr) Format(buffer *TracedBuffer) {
 	buffer.AppendString(buffer.SQLNameFunc(a.Alias))
 }
 
+// TableAlias 作为表来源时的别名
+func (a *AliasExpr) TableAlias() string {
+	return a.Alias
+}
+
+// Col 使用别名限定的列，例如：Alias(N("user"), "u").Col("id") => `u`.`id`
+func (a *AliasExpr) Col(name string) *NameExpr {
+	return Name(name, a.Alias)
+}
+
+// AliasedTable 带有别名的表来源(例如*AliasExpr、meta.AliasedEntity)，连接时使用别名限定列
+type AliasedTable interface {
+	Expr
+	TableAlias() string
+}
+
 // BinaryExpr 二元表达式
 type BinaryExpr struct {
 	Left     Expr
@@ -321,6 +383,19 @@ func Name(name string, qualifiers ...string) *NameExpr {
 	return &NameExpr{Name: name, Qualifier: qualifiers}
 }
 
+// QName 按照从外到内的顺序创建多段名称，每一段可以包含"."
+// 例如：QName("db", "user", "id")和QName("db.user.id")都会被格式化为：`db`.`user`.`id`
+func QName(parts ...string) *NameExpr {
+	var names []string
+	for _, part := range parts {
+		names = append(names, strings.Split(part, ".")...)
+	}
+	if len(names) == 0 {
+		return Name("")
+	}
+	return Name(names[len(names)-1], names[:len(names)-1]...)
+}
+
 func Var(name string, value any) *ValueExpr {
 	return &ValueExpr{Name: name, Value: value}
 }
@@ -339,6 +414,8 @@ func Unary(operator string, expr Expr) *UnaryExpr {
 
 func Binary(left Expr, op string, right any) *BinaryExpr {
 	switch r := right.(type) {
+	case *SelectExpr:
+		return &BinaryExpr{Left: left, Space: " ", Operator: op, Right: SubQuery(r)}
 	case Expr:
 		return &BinaryExpr{Left: left, Space: " ", Operator: op, Right: r}
 	case nil:
@@ -413,11 +490,18 @@ func AutoNamedValues(name string, values ...any) []Expr {
 	return exprs
 }
 
+// In IN表达式，如果values仅包含一个子查询(*SelectExpr或*SubQueryExpr)，则生成IN (SELECT ...)
 func In(left Expr, name string, values ...any) *BinaryExpr {
+	if query, ok := singleQuery(values); ok {
+		return InQuery(left, query)
+	}
 	exprs := AutoNamedValues(name, values...)
 	return InValues(left, exprs...)
 }
 func NotIn(left Expr, name string, values ...any) *BinaryExpr {
+	if query, ok := singleQuery(values); ok {
+		return NotInQuery(left, query)
+	}
 	exprs := AutoNamedValues(name, values...)
 	return NotInValues(left, exprs...)
 }
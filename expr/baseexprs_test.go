@@ -42,6 +42,29 @@ func TestBetween(t *testing.T) {
 	}
 }
 
+func TestNameExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    *NameExpr
+		want    string
+	}{
+		{name: "name", dialect: dialect.MySQL, expr: Name("id"), want: "`id`"},
+		{name: "table qualifier", dialect: dialect.MySQL, expr: Name("id", "user"), want: "`user`.`id`"},
+		{name: "schema and table", dialect: dialect.Postgres, expr: Name("id", "hr", "user"), want: `"hr"."user"."id"`},
+		{name: "database schema and table", dialect: dialect.SQLServer, expr: Name("id", "hr", "dbo", "user"), want: "[hr].[dbo].[user].[id]"},
+		{name: "empty qualifier", dialect: dialect.MySQL, expr: Name("id", "", "u"), want: "`u`.`id`"},
+		{name: "all columns", dialect: dialect.Postgres, expr: Name("*", "u"), want: `"u".*`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(tt.dialect)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
 func TestIn(t *testing.T) {
 	type args struct {
 		left   Expr
@@ -118,3 +141,136 @@ func TestInsertInto(t *testing.T) {
 		})
 	}
 }
+
+func TestNumberedPlaceHolder(t *testing.T) {
+	exp := Select(All).From(N("user")).
+		Where(And(Eq(N("name"), Var("name", "gnodux")), N("id").In(1, 2))).
+		Limit(10).Offset(20)
+
+	sql, args, err := NewTracedBuffer(dialect.Postgres).Build(exp)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE "name" = $1 AND "id" IN ( $2,$3 ) LIMIT $4 OFFSET $5`, sql)
+	assert.Equal(t, []any{"gnodux", 1, 2, 10, 20}, args)
+
+	sql, namedArgs, err := NewTracedBuffer(dialect.Postgres).BuildNamed(exp)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE "name" = :name AND "id" IN ( :id_0,:id_1 ) LIMIT :limit OFFSET :offset`, sql)
+	assert.Equal(t, map[string]any{"name": "gnodux", "id_0": 1, "id_1": 2, "limit": 10, "offset": 20}, namedArgs)
+
+	sql, args, err = NewTracedBuffer(dialect.SQLServer).Build(exp)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM [user] WHERE [name] = ? AND [id] IN ( ?,? ) ORDER BY (SELECT NULL) OFFSET ? ROWS FETCH NEXT ? ROWS ONLY", sql)
+	assert.Equal(t, []any{"gnodux", 1, 2, 20, 10}, args)
+}
+
+func TestUpsert(t *testing.T) {
+	newExpr := func() *InsertExpr {
+		return Upsert(N("user"), N("id")).
+			Values(N("id").Eq(Var("id", 1)), N("name").Eq(Var("name", "gnodux")), N("age").Eq(Var("age", 18)))
+	}
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    *InsertExpr
+		want    string
+	}{
+		{
+			name:    "mysql",
+			dialect: dialect.MySQL,
+			expr:    newExpr().DoUpdateExcluded(N("name")).DoUpdate(N("age").Eq(Raw("`age` + 1"))),
+			want:    "INSERT INTO `user` ( `id`,`name`,`age` ) VALUES ( :id,:name,:age ) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `age` = `age` + 1",
+		}, {
+			name:    "mysql do nothing",
+			dialect: dialect.MySQL,
+			expr:    newExpr().DoNothing(),
+			want:    "INSERT INTO `user` ( `id`,`name`,`age` ) VALUES ( :id,:name,:age ) ON DUPLICATE KEY UPDATE `id` = `id`",
+		}, {
+			name:    "postgres",
+			dialect: dialect.Postgres,
+			expr:    newExpr().DoUpdateExcluded(N("name"), N("age")),
+			want:    `INSERT INTO "user" ( "id","name","age" ) VALUES ( :id,:name,:age ) ON CONFLICT ( "id" ) DO UPDATE SET "name" = EXCLUDED."name", "age" = EXCLUDED."age"`,
+		}, {
+			name:    "postgres do nothing",
+			dialect: dialect.Postgres,
+			expr:    newExpr().DoNothing(),
+			want:    `INSERT INTO "user" ( "id","name","age" ) VALUES ( :id,:name,:age ) ON CONFLICT ( "id" ) DO NOTHING`,
+		}, {
+			name:    "sql server",
+			dialect: dialect.SQLServer,
+			expr:    newExpr().DoUpdateExcluded(N("name"), N("age")),
+			want: "MERGE INTO [user] AS [target] USING (VALUES ( @id,@name,@age )) AS [source] ( [id],[name],[age] ) ON [target].[id] = [source].[id]" +
+				" WHEN MATCHED THEN UPDATE SET [name] = [source].[name], [age] = [source].[age]" +
+				" WHEN NOT MATCHED THEN INSERT ( [id],[name],[age] ) VALUES ( [source].[id],[source].[name],[source].[age] );",
+		}, {
+			name:    "sql server identity",
+			dialect: dialect.SQLServer,
+			expr:    newExpr().Identity(N("id")).DoUpdateExcluded(N("name")),
+			want: "MERGE INTO [user] AS [target] USING (VALUES ( @id,@name,@age )) AS [source] ( [id],[name],[age] ) ON [target].[id] = [source].[id]" +
+				" WHEN MATCHED THEN UPDATE SET [name] = [source].[name]" +
+				" WHEN NOT MATCHED THEN INSERT ( [name],[age] ) VALUES ( [source].[name],[source].[age] );",
+		}, {
+			name:    "sql server do nothing",
+			dialect: dialect.SQLServer,
+			expr:    newExpr().DoNothing(),
+			want: "MERGE INTO [user] AS [target] USING (VALUES ( @id,@name,@age )) AS [source] ( [id],[name],[age] ) ON [target].[id] = [source].[id]" +
+				" WHEN NOT MATCHED THEN INSERT ( [id],[name],[age] ) VALUES ( [source].[id],[source].[name],[source].[age] );",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := NewTracedBuffer(tt.dialect).BuildNamed(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sql)
+			assert.Equal(t, map[string]any{"id": 1, "name": "gnodux", "age": 18}, args)
+		})
+	}
+}
+
+func TestUpsertMergeWithoutKey(t *testing.T) {
+	//新数据没有冲突检测列时无法匹配已有的行
+	_, _, err := NewTracedBuffer(dialect.SQLServer).BuildNamed(Upsert(N("user"), N("id")).Identity(N("id")).
+		Values(N("name").Eq(Var("name", "gnodux"))).DoUpdateExcluded(N("name")))
+	assert.ErrorIs(t, err, ErrNoConflictColumns)
+}
+
+func TestInsertRows(t *testing.T) {
+	newExpr := func() *InsertExpr {
+		return InsertInto(N("user")).Columns(N("name"), N("age")).
+			Row(Var("name_0", "a"), Var("age_0", 1)).
+			Row(Var("name_1", "b"), Var("age_1", 2)).
+			Row(Var("name_2", "c"), Var("age_2", 3))
+	}
+	sql, args, err := NewTracedBuffer(dialect.Postgres).Build(newExpr())
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "user" ( "name","age" ) VALUES ( $1,$2 ),( $3,$4 ),( $5,$6 )`, sql)
+	assert.Equal(t, []any{"a", 1, "b", 2, "c", 3}, args)
+
+	chunks := newExpr().Split(2)
+	if assert.Len(t, chunks, 2) {
+		assert.Equal(t, 2, chunks[0].RowCount())
+		assert.Equal(t, 1, chunks[1].RowCount())
+		sql, namedArgs, err := NewTracedBuffer(dialect.MySQL).BuildNamed(chunks[1])
+		assert.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `user` ( `name`,`age` ) VALUES ( :name_2,:age_2 )", sql)
+		assert.Equal(t, map[string]any{"name_2": "c", "age_2": 3}, namedArgs)
+	}
+
+	sql, _, err = NewTracedBuffer(dialect.MySQL).BuildNamed(InsertInto(N("user_bak")).Columns(N("id"), N("name")).
+		Select(Select(N("id"), N("name")).From(N("user")).Where(Eq(N("role"), Var("role", "admin")))))
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `user_bak` ( `id`,`name` ) SELECT `id`,`name` FROM `user` WHERE `role` = :role", sql)
+
+	sql, _, err = NewTracedBuffer(dialect.SQLServer).BuildNamed(newExpr().OnConflict(N("name")).DoUpdateExcluded(N("age")))
+	assert.NoError(t, err)
+	assert.Equal(t, "MERGE INTO [user] AS [target] USING (VALUES ( @name_0,@age_0 ),( @name_1,@age_1 ),( @name_2,@age_2 )) AS [source] ( [name],[age] )"+
+		" ON [target].[name] = [source].[name] WHEN MATCHED THEN UPDATE SET [age] = [source].[age]"+
+		" WHEN NOT MATCHED THEN INSERT ( [name],[age] ) VALUES ( [source].[name],[source].[age] );", sql)
+}
+
+func TestInsertChunkSize(t *testing.T) {
+	assert.Equal(t, 500, dialect.MySQL.InsertChunkSize(10, 500))
+	assert.Equal(t, 6553, dialect.MySQL.InsertChunkSize(10, 10000))
+	assert.Equal(t, 210, dialect.SQLServer.InsertChunkSize(10, 500))
+	assert.Equal(t, 1000, dialect.SQLServer.InsertChunkSize(2, 5000))
+	assert.Equal(t, 1, dialect.SQLServer.InsertChunkSize(3000, 500))
+}
@@ -0,0 +1,222 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// WhenExpr CASE中的一个分支：WHEN ... THEN ...
+type WhenExpr struct {
+	Cond   Expr
+	Result Expr
+}
+
+func (w *WhenExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendKeyword(keywords.When).AppendString(keywords.Space)
+	w.Cond.Format(buffer)
+	buffer.AppendKeywordWithSpace(keywords.Then)
+	w.Result.Format(buffer)
+}
+
+// CaseExpr CASE表达式
+//
+// Value为空时是搜索CASE：CASE WHEN `age` > 18 THEN 'adult' ELSE 'child' END
+//
+// Value不为空时是简单CASE：CASE `status` WHEN 1 THEN 'on' ELSE 'off' END
+type CaseExpr struct {
+	Value    Expr
+	Whens    []*WhenExpr
+	ElseExpr Expr
+}
+
+// When 添加分支，cond和result不是Expr时使用参数绑定
+func (c *CaseExpr) When(cond any, result any) *CaseExpr {
+	c.Whens = append(c.Whens, &WhenExpr{Cond: bindValue(cond), Result: bindValue(result)})
+	return c
+}
+
+// Else 设置所有分支都不满足时的结果
+func (c *CaseExpr) Else(result any) *CaseExpr {
+	c.ElseExpr = bindValue(result)
+	return c
+}
+
+// As 设置别名，用于查询列
+func (c *CaseExpr) As(alias string) *AliasExpr {
+	return Alias(c, alias)
+}
+
+func (c *CaseExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendKeyword(keywords.Case)
+	if c.Value != nil {
+		buffer.AppendString(keywords.Space)
+		c.Value.Format(buffer)
+	}
+	for _, when := range c.Whens {
+		buffer.AppendString(keywords.Space)
+		when.Format(buffer)
+	}
+	if c.ElseExpr != nil {
+		buffer.AppendKeywordWithSpace(keywords.Else)
+		c.ElseExpr.Format(buffer)
+	}
+	buffer.AppendString(keywords.Space).AppendKeyword(keywords.End)
+}
+
+// Case 创建搜索CASE表达式，例如：Case().When(N("age").Gt(18), "adult").Else("child")
+func Case() *CaseExpr {
+	return &CaseExpr{}
+}
+
+// SimpleCase 创建简单CASE表达式，例如：SimpleCase(N("status")).When(1, "on").Else("off")
+func SimpleCase(value Expr) *CaseExpr {
+	return &CaseExpr{Value: value}
+}
+
+// Coalesce 返回第一个非NULL的值
+func Coalesce(values ...any) *FuncExpr {
+	var args []Expr
+	for _, v := range values {
+		args = append(args, bindValue(v))
+	}
+	return Fn(keywords.Coalesce, args...)
+}
+
+// NullIf 两个值相等时返回NULL，否则返回第一个值
+func NullIf(value any, other any) *FuncExpr {
+	return Fn(keywords.NullIf, bindValue(value), bindValue(other))
+}
+
+// CastExpr 类型转换：CAST(`age` AS CHAR)
+type CastExpr struct {
+	Expr Expr
+	//Type 类型名称，可以使用dialect中的通用类型(例如dialect.TypeString)，格式化时转换为方言中的类型
+	Type string
+}
+
+func (c *CastExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendKeyword(keywords.Cast).AppendString("(")
+	c.Expr.Format(buffer)
+	buffer.AppendKeywordWithSpace(keywords.AS)
+	buffer.AppendString(buffer.TypeName(c.Type))
+	buffer.AppendString(")")
+}
+
+// As 设置别名，用于查询列
+func (c *CastExpr) As(alias string) *AliasExpr {
+	return Alias(c, alias)
+}
+
+// Cast 创建类型转换表达式，例如：Cast(N("age"), dialect.TypeString)
+func Cast(value any, typeName string) *CastExpr {
+	return &CastExpr{Expr: bindValue(value), Type: typeName}
+}
+
+// LiteralExpr 日期字面量：DATE '2023-01-01'，方言不支持时使用CAST('2023-01-01' AS DATE)
+type LiteralExpr struct {
+	//Type 类型名称：dialect.TypeDate、dialect.TypeTime或dialect.TypeDateTime
+	Type  string
+	Value string
+}
+
+func (l *LiteralExpr) Format(buffer *TracedBuffer) {
+	if !buffer.TypedLiteral {
+		buffer.AppendKeyword(keywords.Cast).AppendString("(")
+		buffer.AppendString(buffer.QuoteString(l.Value))
+		buffer.AppendKeywordWithSpace(keywords.AS)
+		buffer.AppendString(buffer.TypeName(l.Type))
+		buffer.AppendString(")")
+		return
+	}
+	switch l.Type {
+	case dialect.TypeDate:
+		buffer.AppendKeyword(keywords.Date)
+	case dialect.TypeTime:
+		buffer.AppendKeyword(keywords.Time)
+	default:
+		buffer.AppendKeyword(keywords.Timestamp)
+	}
+	buffer.AppendString(keywords.Space).AppendString(buffer.QuoteString(l.Value))
+}
+
+// Date 日期字面量，例如：Date("2023-01-01")
+func Date(value string) *LiteralExpr {
+	return &LiteralExpr{Type: dialect.TypeDate, Value: value}
+}
+
+// Time 时间字面量，例如：Time("12:00:00")
+func Time(value string) *LiteralExpr {
+	return &LiteralExpr{Type: dialect.TypeTime, Value: value}
+}
+
+// Timestamp 日期时间字面量，例如：Timestamp("2023-01-01 12:00:00")
+func Timestamp(value string) *LiteralExpr {
+	return &LiteralExpr{Type: dialect.TypeDateTime, Value: value}
+}
+
+// DistinctExpr NULL安全的比较：IS [NOT] DISTINCT FROM
+// 方言定义了NullSafeEqual(MySQL)时使用 <=> 代替
+type DistinctExpr struct {
+	Left  Expr
+	Right Expr
+	//Not 为true时表示IS NOT DISTINCT FROM(相等)
+	Not bool
+}
+
+func (d *DistinctExpr) Format(buffer *TracedBuffer) {
+	if buffer.NullSafeEqual != "" {
+		equal := Binary(d.Left, buffer.NullSafeEqual, d.Right)
+		if d.Not {
+			equal.Format(buffer)
+		} else {
+			Not(Paren(equal)).Format(buffer)
+		}
+		return
+	}
+	d.Left.Format(buffer)
+	if d.Not {
+		buffer.AppendKeywordWithSpace(keywords.IsNotDistinctFrom)
+	} else {
+		buffer.AppendKeywordWithSpace(keywords.IsDistinctFrom)
+	}
+	d.Right.Format(buffer)
+}
+
+// IsNull 判断是否为NULL：`name` IS NULL
+func IsNull(exp Expr) *BinaryExpr {
+	return &BinaryExpr{Left: exp, Space: keywords.Space, Operator: keywords.Is, Right: NULL}
+}
+
+// IsNotNull 判断是否不为NULL：`name` IS NOT NULL
+func IsNotNull(exp Expr) *BinaryExpr {
+	return &BinaryExpr{Left: exp, Space: keywords.Space, Operator: keywords.IsNot, Right: NULL}
+}
+
+// IsDistinctFrom NULL安全的不等于，NULL和NULL视为相等
+func IsDistinctFrom(left Expr, right any) *DistinctExpr {
+	return &DistinctExpr{Left: left, Right: bindValue(right)}
+}
+
+// IsNotDistinctFrom NULL安全的等于，NULL和NULL视为相等
+func IsNotDistinctFrom(left Expr, right any) *DistinctExpr {
+	return &DistinctExpr{Left: left, Right: bindValue(right), Not: true}
+}
+
+// bindValue 将值转换为表达式，不是Expr的值使用自动命名的参数绑定
+func bindValue(v any) Expr {
+	switch e := v.(type) {
+	case *SelectExpr:
+		return SubQuery(e)
+	case Expr:
+		return e
+	case nil:
+		return NULL
+	default:
+		return Var(autoParam(), v)
+	}
+}
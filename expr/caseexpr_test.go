@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConditionalExprs(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    Expr
+		want    string
+	}{
+		{
+			name:    "searched case",
+			dialect: dialect.MySQL,
+			expr:    Case().When(N("age").Ge(Const(18)), Const("adult")).Else(Const("child")).As("stage"),
+			want:    "CASE WHEN `age` >= 18 THEN 'adult' ELSE 'child' END AS `stage`",
+		}, {
+			name:    "simple case",
+			dialect: dialect.Postgres,
+			expr:    N("status").Case().When(Const(1), Const("on")).When(Const(0), Const("off")),
+			want:    `CASE "status" WHEN 1 THEN 'on' WHEN 0 THEN 'off' END`,
+		}, {
+			name:    "coalesce",
+			dialect: dialect.MySQL,
+			expr:    N("nick").Coalesce(N("name"), Const("")),
+			want:    "COALESCE(`nick`,`name`,'')",
+		}, {
+			name:    "nullif",
+			dialect: dialect.SQLServer,
+			expr:    N("name").NullIf(Const("")),
+			want:    "NULLIF([name],'')",
+		}, {
+			name:    "cast(mysql)",
+			dialect: dialect.MySQL,
+			expr:    N("age").Cast(dialect.TypeString),
+			want:    "CAST(`age` AS CHAR)",
+		}, {
+			name:    "cast(postgres)",
+			dialect: dialect.Postgres,
+			expr:    N("age").Cast(dialect.TypeString),
+			want:    `CAST("age" AS VARCHAR)`,
+		}, {
+			name:    "cast with length(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    N("age").Cast("STRING(20)"),
+			want:    "CAST([age] AS NVARCHAR(20))",
+		}, {
+			name:    "cast(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    N("age").Cast(dialect.TypeString),
+			want:    "CAST([age] AS NVARCHAR(MAX))",
+		}, {
+			name:    "cast unmapped",
+			dialect: dialect.Postgres,
+			expr:    Cast(N("price"), "DECIMAL(10,2)"),
+			want:    `CAST("price" AS NUMERIC(10,2))`,
+		}, {
+			name:    "is null",
+			dialect: dialect.MySQL,
+			expr:    And(N("deleted_at").IsNull(), N("name").IsNotNull()),
+			want:    "`deleted_at` IS NULL AND `name` IS NOT NULL",
+		}, {
+			name:    "is distinct from(postgres)",
+			dialect: dialect.Postgres,
+			expr:    And(N("a").IsDistinctFrom(N("b")), N("c").IsNotDistinctFrom(nil)),
+			want:    `"a" IS DISTINCT FROM "b" AND "c" IS NOT DISTINCT FROM NULL`,
+		}, {
+			name:    "is distinct from(mysql)",
+			dialect: dialect.MySQL,
+			expr:    And(N("a").IsDistinctFrom(N("b")), N("c").IsNotDistinctFrom(nil)),
+			want:    "NOT ( `a` <=> `b` ) AND `c` <=> NULL",
+		}, {
+			name:    "date literal",
+			dialect: dialect.Postgres,
+			expr:    And(N("birthday").Ge(Date("2000-01-01")), N("created_at").Lt(Timestamp("2023-01-01 00:00:00"))),
+			want:    `"birthday" >= DATE '2000-01-01' AND "created_at" < TIMESTAMP '2023-01-01 00:00:00'`,
+		}, {
+			name:    "date literal(sqlserver)",
+			dialect: dialect.SQLServer,
+			expr:    And(N("birthday").Ge(Date("2000-01-01")), N("created_at").Lt(Timestamp("2023-01-01 00:00:00"))),
+			want:    "[birthday] >= CAST('2000-01-01' AS DATE) AND [created_at] < CAST('2023-01-01 00:00:00' AS DATETIME2)",
+		}, {
+			name:    "boolean and string(sqlserver)",
+			dialect: dialect.SQLServer,
+			expr:    And(N("active").Eq(Const(true)), N("name").Eq(Const("O'Brien"))),
+			want:    "[active] = 1 AND [name] = 'O''Brien'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(tt.dialect)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestCaseBinding(t *testing.T) {
+	exp := Case().When(N("age").Ge(Var("age", 18)), "adult").Else("child")
+	query, args, err := NewTracedBuffer(dialect.Postgres).Build(exp)
+	assert.NoError(t, err)
+	assert.Equal(t, `CASE WHEN "age" >= $1 THEN $2 ELSE $3 END`, query)
+	assert.Equal(t, []any{18, "adult", "child"}, args)
+}
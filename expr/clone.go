@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+// Clone 深拷贝表达式树，拷贝后的表达式与原表达式不共享任何可修改的节点
+//
+// 包内的共享常量(All、NULL、LeftBracket、RightBracket)保持原引用，包外的表达式(例如meta.Entity、meta.Column)视为不可变，不做拷贝
+func Clone(exp Expr) Expr {
+	if exp == nil {
+		return nil
+	}
+	c := shallowCopy(exp)
+	rewriteChildren(c, Clone)
+	return c
+}
+
+// Clone 深拷贝查询，可以在预先构建的查询基础上为每个请求单独定制
+func (s *SelectExpr) Clone() *SelectExpr {
+	if s == nil {
+		return nil
+	}
+	return Clone(s).(*SelectExpr)
+}
+
+// Clone 深拷贝更新语句
+func (u *UpdateExpr) Clone() *UpdateExpr {
+	if u == nil {
+		return nil
+	}
+	return Clone(u).(*UpdateExpr)
+}
+
+// Clone 深拷贝删除语句
+func (d *DeleteExpr) Clone() *DeleteExpr {
+	if d == nil {
+		return nil
+	}
+	return Clone(d).(*DeleteExpr)
+}
+
+// Clone 深拷贝插入语句
+func (i *InsertExpr) Clone() *InsertExpr {
+	if i == nil {
+		return nil
+	}
+	return Clone(i).(*InsertExpr)
+}
+
+// shallowCopy 拷贝节点本身及其切片，子节点由rewriteChildren替换为拷贝
+func shallowCopy(exp Expr) Expr {
+	switch e := exp.(type) {
+	case *RawExpr:
+		if e == All || e == LeftBracket || e == RightBracket {
+			return e
+		}
+		c := *e
+		return &c
+	case *ConstantExpr:
+		if e == NULL {
+			return e
+		}
+		c := *e
+		return &c
+	case *NameExpr:
+		c := *e
+		c.Qualifier = copySlice(e.Qualifier)
+		return &c
+	case *ValueExpr:
+		c := *e
+		return &c
+	case *AroundExpr:
+		c := *e
+		return &c
+	case *AliasExpr:
+		c := *e
+		return &c
+	case *BinaryExpr:
+		c := *e
+		return &c
+	case *UnaryExpr:
+		c := *e
+		return &c
+	case *ListExpr:
+		c := *e
+		c.ExprList = copySlice(e.ExprList)
+		return &c
+	case *FuncExpr:
+		c := *e
+		c.Args = copySlice(e.Args)
+		return &c
+	case *BetweenExpr:
+		c := *e
+		return &c
+	case *SubQueryExpr:
+		c := *e
+		return &c
+	case *SelectExpr:
+		c := *e
+		c.Joins = copySlice(e.Joins)
+		c.Windows = copySlice(e.Windows)
+		c.Compounds = copySlice(e.Compounds)
+		return &c
+	case *JoinExpr:
+		c := *e
+		c.UsingExprs = copySlice(e.UsingExprs)
+		return &c
+	case *WithExpr:
+		c := *e
+		c.CTEs = copySlice(e.CTEs)
+		return &c
+	case *CTE:
+		c := *e
+		c.Columns = copySlice(e.Columns)
+		return &c
+	case *CompoundExpr:
+		c := *e
+		return &c
+	case *FrameExpr:
+		c := *e
+		return &c
+	case *WindowExpr:
+		c := *e
+		c.PartitionByExprs = copySlice(e.PartitionByExprs)
+		return &c
+	case *OverExpr:
+		c := *e
+		return &c
+	case *NamedWindowExpr:
+		c := *e
+		return &c
+	case *InsertExpr:
+		c := *e
+		c.ValueExprs = copySlice(e.ValueExprs)
+		c.ColumnExprs = copySlice(e.ColumnExprs)
+		c.RowExprs = copySlice(e.RowExprs)
+		for idx := range c.RowExprs {
+			c.RowExprs[idx] = copySlice(c.RowExprs[idx])
+		}
+		c.ConflictColumns = copySlice(e.ConflictColumns)
+		c.UpdateExprs = copySlice(e.UpdateExprs)
+		c.ReturningExprs = copySlice(e.ReturningExprs)
+		return &c
+	case *ExcludedExpr:
+		c := *e
+		return &c
+	case *UpdateExpr:
+		c := *e
+		c.Values = copySlice(e.Values)
+		c.ReturningExprs = copySlice(e.ReturningExprs)
+		return &c
+	case *DeleteExpr:
+		c := *e
+		c.ReturningExprs = copySlice(e.ReturningExprs)
+		return &c
+	case *LockExpr:
+		c := *e
+		return &c
+	case *CaseExpr:
+		c := *e
+		c.Whens = copySlice(e.Whens)
+		return &c
+	case *WhenExpr:
+		c := *e
+		return &c
+	case *CastExpr:
+		c := *e
+		return &c
+	case *DistinctExpr:
+		c := *e
+		return &c
+	case *LiteralExpr:
+		c := *e
+		return &c
+	default:
+		return exp
+	}
+}
+
+func copySlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func format(exp Expr) string {
+	buf := NewTracedBuffer(dialect.MySQL)
+	exp.Format(buf)
+	return buf.String()
+}
+
+func TestClone(t *testing.T) {
+	base := With("t", Select(N("id")).From(N("dept"))).
+		Select(N("id"), RowNumber().Over(Window("").OrderBy(N("age")))).
+		From(N("user")).
+		LeftJoin(N("t"), Eq(N("dept_id"), N("id", "t"))).
+		Where(And(N("age").Gt(Const(18)), N("name").Like(Const("a%")))).
+		OrderBy(N("id")).ForUpdate()
+	want := format(base)
+
+	clone := base.Clone()
+	assert.Equal(t, want, format(clone))
+
+	//修改拷贝不影响原查询
+	AllToOr(clone)
+	UseRewrite(func(e Expr) Expr {
+		if n, ok := e.(*NameExpr); ok {
+			n.Name = "x_" + n.Name
+		}
+		return e
+	})(clone)
+	clone.Limit(10).SkipLocked()
+	assert.Equal(t, want, format(base))
+	assert.NotEqual(t, want, format(clone))
+
+	//共享常量保持原引用
+	assert.Same(t, All, Clone(All))
+	assert.Same(t, NULL, Clone(NULL))
+
+	insert := InsertInto(N("user")).Columns(N("id")).Row(Const(1)).Row(Const(2)).Returning(N("id"))
+	insertClone := insert.Clone()
+	insertClone.RowExprs[0][0] = Const(3)
+	insertClone.Row(Const(4))
+	assert.Equal(t, "INSERT INTO `user` ( `id` ) VALUES ( 1 ),( 2 )", format(insert))
+	assert.Equal(t, "INSERT INTO `user` ( `id` ) VALUES ( 3 ),( 2 ),( 4 )", format(insertClone))
+}
+
+func TestCloneConcurrent(t *testing.T) {
+	base := Select(All).From(N("user")).Where(And(N("name").Eq(Const("a%"))))
+	want := format(base)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q := base.Clone()
+			AutoFuzzy(q)
+			q.Limit(i + 1)
+			format(q)
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, want, format(base))
+}
+
+func TestBuildCountExprNotShared(t *testing.T) {
+	query := Select(All).From(N("user")).Where(And(N("name").Eq(Const("a%"))))
+	count := query.BuildCountExpr()
+	AutoFuzzy(count)
+	assert.Equal(t, "SELECT * FROM `user` WHERE `name` = 'a%'", format(query))
+	assert.Equal(t, "SELECT COUNT(1) FROM `user` WHERE `name` LIKE 'a%'", format(count))
+}
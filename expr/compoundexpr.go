@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import "github.com/gnodux/sqlmx/expr/keywords"
+
+// CompoundExpr 复合查询的组成部分，例如：UNION ALL SELECT ...
+type CompoundExpr struct {
+	Operator string
+	Query    Expr
+}
+
+func (c *CompoundExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendKeyword(c.Operator).AppendString(keywords.Space)
+	//查询自身的ORDER BY、LIMIT或复合查询使用括号包裹，否则会作用于整个复合查询
+	if query, ok := c.Query.(*SelectExpr); ok && (query.OrderByExpr != nil || query.limit != 0 || len(query.Compounds) > 0) {
+		Paren(query).Format(buffer)
+		return
+	}
+	c.Query.Format(buffer)
+}
+
+// Compound 添加复合查询，operator为keywords.Union、keywords.UnionAll、keywords.Intersect或keywords.Except
+//
+// 存在复合查询时，当前查询的ORDER BY和LIMIT/OFFSET作用于整个复合查询的结果；
+// queries自身的ORDER BY、LIMIT/OFFSET只作用于该查询，格式化时使用括号包裹
+func (s *SelectExpr) Compound(operator string, queries ...Expr) *SelectExpr {
+	for _, query := range queries {
+		s.Compounds = append(s.Compounds, &CompoundExpr{Operator: operator, Query: query})
+	}
+	return s
+}
+func (s *SelectExpr) Union(queries ...Expr) *SelectExpr {
+	return s.Compound(keywords.Union, queries...)
+}
+func (s *SelectExpr) UnionAll(queries ...Expr) *SelectExpr {
+	return s.Compound(keywords.UnionAll, queries...)
+}
+func (s *SelectExpr) Intersect(queries ...Expr) *SelectExpr {
+	return s.Compound(keywords.Intersect, queries...)
+}
+func (s *SelectExpr) Except(queries ...Expr) *SelectExpr {
+	return s.Compound(keywords.Except, queries...)
+}
+
+// Union 使用UNION连接多个查询，返回第一个查询，后续的OrderBy、Limit等作用于整个结果
+// 例如：Union(Select(N("id")).From(N("a")), Select(N("id")).From(N("b"))).OrderBy(N("id")).Limit(10)
+func Union(first *SelectExpr, queries ...Expr) *SelectExpr {
+	return first.Union(queries...)
+}
+
+// UnionAll 使用UNION ALL连接多个查询
+func UnionAll(first *SelectExpr, queries ...Expr) *SelectExpr {
+	return first.UnionAll(queries...)
+}
+
+// Intersect 使用INTERSECT连接多个查询
+func Intersect(first *SelectExpr, queries ...Expr) *SelectExpr {
+	return first.Intersect(queries...)
+}
+
+// Except 使用EXCEPT连接多个查询
+func Except(first *SelectExpr, queries ...Expr) *SelectExpr {
+	return first.Except(queries...)
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr/keywords"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompound(t *testing.T) {
+	tests := []struct {
+		name string
+		expr Expr
+		want string
+	}{
+		{
+			name: "union",
+			expr: Union(Select(N("id")).From(N("user")), Select(N("id")).From(N("role"))),
+			want: "SELECT `id` FROM `user` UNION SELECT `id` FROM `role`",
+		}, {
+			name: "union all with order by",
+			expr: UnionAll(Select(N("name")).From(N("user")).Where(Eq(N("role"), Const("admin"))), Select(N("name")).From(N("role"))).
+				OrderBy(N("name")),
+			want: "SELECT `name` FROM `user` WHERE `role` = 'admin' UNION ALL SELECT `name` FROM `role` ORDER BY `name`",
+		}, {
+			name: "mixed",
+			expr: Select(N("id")).From(N("a")).Intersect(Select(N("id")).From(N("b"))).Except(Select(N("id")).From(N("c"))),
+			want: "SELECT `id` FROM `a` INTERSECT SELECT `id` FROM `b` EXCEPT SELECT `id` FROM `c`",
+		}, {
+			name: "count",
+			expr: Union(Select(N("id")).From(N("user")), Select(N("id")).From(N("role"))).OrderBy(N("id")).Limit(10).BuildCountExpr(),
+			want: "SELECT COUNT(1) FROM (SELECT `id` FROM `user` UNION SELECT `id` FROM `role`) AS `t`",
+		}, {
+			name: "member order by and limit",
+			expr: Union(Select(N("id")).From(N("user")), Select(N("id")).From(N("role")).OrderBy(N("id")).Limit(5)).Limit(10),
+			want: "SELECT `id` FROM `user` UNION ( SELECT `id` FROM `role` ORDER BY `id` LIMIT :limit OFFSET :offset ) LIMIT :limit_1 OFFSET :offset_1",
+		}, {
+			name: "nested compound",
+			expr: Union(Select(N("id")).From(N("a")), Select(N("id")).From(N("b")).Except(Select(N("id")).From(N("c")))),
+			want: "SELECT `id` FROM `a` UNION ( SELECT `id` FROM `b` EXCEPT SELECT `id` FROM `c` )",
+		}, {
+			name: "filter",
+			expr: func() Expr {
+				s := Select(N("id")).From(N("user"))
+				UseCompound(keywords.Except, Select(N("id")).From(N("role")))(s)
+				return s
+			}(),
+			want: "SELECT `id` FROM `user` EXCEPT SELECT `id` FROM `role`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(dialect.MySQL)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -8,25 +8,37 @@ package expr
 import "github.com/gnodux/sqlmx/expr/keywords"
 
 type DeleteExpr struct {
+	WithExpr  *WithExpr
 	Table     Expr
 	WhereExpr Expr
+	//ReturningExprs 返回被删除数据的列
+	ReturningExprs []Expr
 }
 
 func (d *DeleteExpr) Delete(table Expr) *DeleteExpr {
 	d.Table = table
 	return d
 }
+
+// With 设置WITH子句
+func (d *DeleteExpr) With(w *WithExpr) *DeleteExpr {
+	d.WithExpr = w
+	return d
+}
 func (d *DeleteExpr) Where(exp Expr) *DeleteExpr {
 	d.WhereExpr = exp
 	return d
 }
 func (d *DeleteExpr) Format(buf *TracedBuffer) {
+	formatWith(d.WithExpr, buf)
 	buf.AppendKeyword(keywords.Delete).AppendString(keywords.Space).AppendKeyword(keywords.From).AppendString(keywords.Space)
 	d.Table.Format(buf)
+	formatOutput(buf, keywords.Deleted, d.ReturningExprs)
 	if d.WhereExpr != nil {
 		buf.AppendKeywordWithSpace(keywords.Where)
 		d.WhereExpr.Format(buf)
 	}
+	formatReturning(buf, d.ReturningExprs)
 }
 func Delete(table Expr) *DeleteExpr {
 	return &DeleteExpr{Table: table}
@@ -168,32 +168,27 @@ func AutoFuzzy(exp Expr) {
 }
 
 func fuzzy(exp Expr) {
-	if exp == nil {
-		return
-	}
-	switch n := exp.(type) {
-	case *ListExpr:
-		for _, v := range n.ExprList {
-			fuzzy(v)
-		}
-	case *BinaryExpr:
-		switch right := n.Right.(type) {
-		case *ValueExpr:
-			if isStringAndFuzzy(right.Value) {
-				n.Space = keywords.Space
-				n.Operator = keywords.Like
+	Walk(exp, func(e Expr) bool {
+		switch n := e.(type) {
+		case *SelectExpr, *SubQueryExpr:
+			//子查询的条件不做处理
+			return false
+		case *BinaryExpr:
+			switch right := n.Right.(type) {
+			case *ValueExpr:
+				if isStringAndFuzzy(right.Value) {
+					n.Space = keywords.Space
+					n.Operator = keywords.Like
+				}
+			case *ConstantExpr:
+				if isStringAndFuzzy(right.Value) {
+					n.Space = keywords.Space
+					n.Operator = keywords.Like
+				}
 			}
-		case *ConstantExpr:
-			if isStringAndFuzzy(right.Value) {
-				n.Space = keywords.Space
-				n.Operator = keywords.Like
-			}
-		}
-	case *AroundExpr:
-		if n.Prefix == LeftBracket && n.Suffix == RightBracket {
-			fuzzy(n.Expr)
 		}
-	}
+		return true
+	})
 }
 func isStringAndFuzzy(v any) bool {
 	switch vv := v.(type) {
@@ -216,8 +211,48 @@ func UseSort(direct string, exprs ...Expr) FilterFn {
 		s.OrderByExpr = Sorts(direct, exprs...)
 	})
 }
+
+// UseJoin 添加连接表达式
+func UseJoin(joins ...*JoinExpr) FilterFn {
+	return SelectFilter(func(s *SelectExpr) {
+		s.Join(joins...)
+	})
+}
+
+// UseCompound 添加复合查询(UNION/UNION ALL/INTERSECT/EXCEPT)，排序和分页作用于整个结果
+func UseCompound(operator string, queries ...Expr) FilterFn {
+	return SelectFilter(func(s *SelectExpr) {
+		s.Compound(operator, queries...)
+	})
+}
+func UseUnion(queries ...Expr) FilterFn {
+	return UseCompound(keywords.Union, queries...)
+}
+func UseUnionAll(queries ...Expr) FilterFn {
+	return UseCompound(keywords.UnionAll, queries...)
+}
 func UseOrderBy(exp Expr) FilterFn {
 	return SelectFilter(func(s *SelectExpr) {
 		s.OrderByExpr = exp
 	})
 }
+
+// UseLock 查询时加锁，需要在事务中使用，例如：UseLock(keywords.ForUpdate, keywords.SkipLocked)
+func UseLock(mode string, wait ...string) FilterFn {
+	return SelectFilter(func(s *SelectExpr) {
+		s.Lock(mode, wait...)
+	})
+}
+
+// UseForUpdate 查询时使用排他锁(FOR UPDATE)
+func UseForUpdate(wait ...string) FilterFn {
+	return UseLock(keywords.ForUpdate, wait...)
+}
+
+// UseRewrite 使用fn改写整个语句，用于租户条件注入、列名替换等通用改写
+// 语句本身只能原地修改，fn对根节点返回的替换结果会被忽略
+func UseRewrite(fn RewriteFn) FilterFn {
+	return func(exp Expr) {
+		Rewrite(exp, fn)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// ErrJoinUsing 方言不支持USING且连接的表没有引用名称(例如没有别名的子查询)，无法转换为ON条件
+var ErrJoinUsing = errors.New("join using requires named tables")
+
+// JoinExpr 连接表达式
+// 例如：LeftJoin(Alias(N("role"), "r"), Eq(N("role", "u"), N("name", "r")))，mysql 驱动下则会被格式化为：
+// LEFT JOIN `role` AS `r` ON `u`.`role` = `r`.`name`
+type JoinExpr struct {
+	Type       string
+	Table      Expr
+	OnExpr     Expr
+	UsingExprs []Expr
+}
+
+// On 设置连接条件
+func (j *JoinExpr) On(exp Expr) *JoinExpr {
+	j.OnExpr = exp
+	return j
+}
+
+// Using 设置USING连接列
+func (j *JoinExpr) Using(cols ...Expr) *JoinExpr {
+	j.UsingExprs = cols
+	return j
+}
+
+// Format 格式化连接表达式
+// 方言不支持USING时需要左表信息才能转换为ON条件，此时应通过SelectExpr格式化
+func (j *JoinExpr) Format(buffer *TracedBuffer) {
+	j.format(buffer, nil)
+}
+
+func (j *JoinExpr) format(buffer *TracedBuffer, left Expr) {
+	buffer.AppendKeyword(j.Type).AppendString(keywords.Space)
+	j.Table.Format(buffer)
+	switch {
+	case j.OnExpr != nil:
+		buffer.AppendKeywordWithSpace(keywords.On)
+		j.OnExpr.Format(buffer)
+	case len(j.UsingExprs) > 0:
+		leftRef, leftOk := tableRef(left)
+		rightRef, rightOk := tableRef(j.Table)
+		switch {
+		case buffer.SupportJoinUsing:
+			buffer.AppendKeywordWithSpace(keywords.Using)
+			Paren(List(keywords.Comma, j.UsingExprs...)).Format(buffer)
+		case !leftOk || !rightOk:
+			//无法获得表的引用名称时(例如没有别名的子查询)不能转换为ON条件，需要为表指定别名
+			buffer.AddError(fmt.Errorf("%w: %s USING requires aliased tables on %s", ErrJoinUsing, j.Type, buffer.Name))
+		default:
+			buffer.AppendKeywordWithSpace(keywords.On)
+			usingToOn(leftRef, rightRef, j.UsingExprs).Format(buffer)
+		}
+	}
+}
+
+// usingToOn 将USING列转换为等值的ON条件，例如：USING(id) => `l`.`id` = `r`.`id`
+func usingToOn(leftRef, rightRef string, cols []Expr) Expr {
+	var conditions []Expr
+	for _, col := range cols {
+		name := exprName(col)
+		conditions = append(conditions, Eq(Name(name, leftRef), Name(name, rightRef)))
+	}
+	return And(conditions...)
+}
+
+// tableRef 获取表的引用名称，有别名时使用别名；不是表名或别名(例如子查询)时返回false
+func tableRef(table Expr) (string, bool) {
+	switch t := table.(type) {
+	case AliasedTable:
+		return t.TableAlias(), true
+	case *NameExpr:
+		return t.Name, true
+	case fmt.Stringer:
+		return t.String(), true
+	default:
+		return "", false
+	}
+}
+
+// exprName 获取名称表达式的名称（不包含限定名称）
+func exprName(exp Expr) string {
+	switch e := exp.(type) {
+	case *NameExpr:
+		return e.Name
+	case fmt.Stringer:
+		return e.String()
+	default:
+		return fmt.Sprintf("%v", e)
+	}
+}
+
+// Join 创建一个连接表达式，joinType为连接类型，例如：keywords.InnerJoin
+func Join(joinType string, table Expr, on Expr) *JoinExpr {
+	return &JoinExpr{Type: joinType, Table: table, OnExpr: on}
+}
+
+// JoinUsing 创建一个使用USING条件的连接表达式
+func JoinUsing(joinType string, table Expr, cols ...Expr) *JoinExpr {
+	return &JoinExpr{Type: joinType, Table: table, UsingExprs: cols}
+}
+
+// InnerJoin 内连接
+func InnerJoin(table Expr, on Expr) *JoinExpr {
+	return Join(keywords.InnerJoin, table, on)
+}
+
+// LeftJoin 左连接
+func LeftJoin(table Expr, on Expr) *JoinExpr {
+	return Join(keywords.LeftJoin, table, on)
+}
+
+// RightJoin 右连接
+func RightJoin(table Expr, on Expr) *JoinExpr {
+	return Join(keywords.RightJoin, table, on)
+}
+
+// FullJoin 全连接(MySQL不支持)
+func FullJoin(table Expr, on Expr) *JoinExpr {
+	return Join(keywords.FullJoin, table, on)
+}
+
+// CrossJoin 交叉连接
+func CrossJoin(table Expr) *JoinExpr {
+	return Join(keywords.CrossJoin, table, nil)
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    Expr
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "inner join",
+			dialect: dialect.MySQL,
+			expr: Select(N("id", "u"), N("name", "r")).From(Alias(N("user"), "u")).
+				InnerJoin(Alias(N("role"), "r"), Eq(N("role", "u"), N("name", "r"))),
+			want: "SELECT `u`.`id`,`r`.`name` FROM `user` AS `u` INNER JOIN `role` AS `r` ON `u`.`role` = `r`.`name`",
+		}, {
+			name:    "left join with where",
+			dialect: dialect.MySQL,
+			expr: Select(All).From(Alias(N("user"), "u")).
+				LeftJoin(Alias(N("role"), "r"), Eq(N("role", "u"), N("name", "r"))).
+				Where(Eq(N("id", "u"), Raw(1))),
+			want: "SELECT * FROM `user` AS `u` LEFT JOIN `role` AS `r` ON `u`.`role` = `r`.`name` WHERE `u`.`id` = 1",
+		}, {
+			name:    "multiple joins",
+			dialect: dialect.Postgres,
+			expr: Select(All).From(N("a")).
+				RightJoin(N("b"), Eq(N("id", "a"), N("a_id", "b"))).
+				FullJoin(N("c"), Eq(N("id", "b"), N("b_id", "c"))).
+				CrossJoin(N("d")),
+			want: `SELECT * FROM "a" RIGHT JOIN "b" ON "a"."id" = "b"."a_id" FULL JOIN "c" ON "b"."id" = "c"."b_id" CROSS JOIN "d"`,
+		}, {
+			name:    "using",
+			dialect: dialect.MySQL,
+			expr:    Select(All).From(N("user")).Join(JoinUsing("INNER JOIN", N("profile"), N("id"), N("tenant_id"))),
+			want:    "SELECT * FROM `user` INNER JOIN `profile` USING ( `id`,`tenant_id` )",
+		}, {
+			name:    "using(sql server)",
+			dialect: dialect.SQLServer,
+			expr: Select(All).From(Alias(N("user"), "u")).
+				Join(LeftJoin(Alias(N("profile"), "p"), nil).Using(N("id"), N("tenant_id"))),
+			want: "SELECT * FROM [user] AS [u] LEFT JOIN [profile] AS [p] ON [u].[id] = [p].[id] AND [u].[tenant_id] = [p].[tenant_id]",
+		}, {
+			name:    "using subquery(sql server)",
+			dialect: dialect.SQLServer,
+			expr: Select(All).From(N("user")).
+				Join(JoinUsing("INNER JOIN", Paren(Select(N("id")).From(N("profile"))), N("id"))),
+			want:    "SELECT * FROM [user] INNER JOIN ( SELECT [id] FROM [profile] )",
+			wantErr: ErrJoinUsing,
+		}, {
+			name:    "using aliased subquery(sql server)",
+			dialect: dialect.SQLServer,
+			expr: Select(All).From(N("user")).
+				Join(JoinUsing("INNER JOIN", Alias(Paren(Select(N("id")).From(N("profile"))), "p"), N("id"))),
+			want: "SELECT * FROM [user] INNER JOIN ( SELECT [id] FROM [profile] ) AS [p] ON [user].[id] = [p].[id]",
+		}, {
+			name:    "self join",
+			dialect: dialect.MySQL,
+			expr: Select(N("name", "e"), Alias(N("name", "m"), "manager")).From(Alias(N("employee"), "e")).
+				LeftJoin(Alias(N("employee"), "m"), Eq(N("manager_id", "e"), N("id", "m"))),
+			want: "SELECT `e`.`name`,`m`.`name` AS `manager` FROM `employee` AS `e` LEFT JOIN `employee` AS `m` ON `e`.`manager_id` = `m`.`id`",
+		}, {
+			name:    "multi-part names",
+			dialect: dialect.SQLServer,
+			expr: Select(QName("u.id"), N("name", "hr", "dept")).From(QName("hr", "dbo", "user").As("u")).
+				InnerJoin(QName("hr.dbo.dept"), Eq(N("dept_id", "u"), QName("hr", "dbo.dept", "id"))),
+			want: "SELECT [u].[id],[hr].[dept].[name] FROM [hr].[dbo].[user] AS [u] INNER JOIN [hr].[dbo].[dept] ON [u].[dept_id] = [hr].[dbo].[dept].[id]",
+		}, {
+			name:    "alias columns",
+			dialect: dialect.Postgres,
+			expr: func() Expr {
+				e, m := N("employee").As("e"), N("employee").As("m")
+				return Select(e.Col("name"), m.Col("*")).From(e).LeftJoin(m, e.Col("manager_id").Eq(m.Col("id")))
+			}(),
+			want: `SELECT "e"."name","m".* FROM "employee" AS "e" LEFT JOIN "employee" AS "m" ON "e"."manager_id" = "m"."id"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(tt.dialect)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+			assert.ErrorIs(t, buf.Err(), tt.wantErr)
+		})
+	}
+}
+
+func TestJoinFilter(t *testing.T) {
+	s := Select(All).From(N("user"))
+	UseJoin(LeftJoin(N("role"), Eq(N("role", "user"), N("name", "role"))))(s)
+	sql, _, err := NewTracedBuffer(dialect.MySQL).BuildNamed(s.BuildCountExpr())
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT COUNT(1) FROM `user` LEFT JOIN `role` ON `user`.`role` = `role`.`name`", sql)
+}
@@ -6,30 +6,31 @@
 package keywords
 
 const (
-	Asc     = "ASC"
-	Desc    = "DESC"
-	Having  = "HAVING"
-	Where   = "WHERE"
-	And     = "AND"
-	Or      = "OR"
-	GroupBy = "GROUP BY"
-	OrderBy = "ORDER BY"
-	Select  = "SELECT"
-	From    = "FROM"
-	In      = "IN"
-	Between = "BETWEEN"
-	Not     = "NOT"
-	NotIn   = "NOT IN"
-	Exists  = "EXISTS"
-	Like    = "LIKE"
-	All     = "*"
-	Comma   = ","
-	Limit   = "LIMIT"
-	Offset  = "OFFSET"
-	Count   = "COUNT"
-	Set     = "SET"
-	Insert  = "INSERT"
-	Into    = "INTO"
+	Asc       = "ASC"
+	Desc      = "DESC"
+	Having    = "HAVING"
+	Where     = "WHERE"
+	And       = "AND"
+	Or        = "OR"
+	GroupBy   = "GROUP BY"
+	OrderBy   = "ORDER BY"
+	Select    = "SELECT"
+	From      = "FROM"
+	In        = "IN"
+	Between   = "BETWEEN"
+	Not       = "NOT"
+	NotIn     = "NOT IN"
+	Exists    = "EXISTS"
+	NotExists = "NOT EXISTS"
+	Like      = "LIKE"
+	All       = "*"
+	Comma     = ","
+	Limit     = "LIMIT"
+	Offset    = "OFFSET"
+	Count     = "COUNT"
+	Set       = "SET"
+	Insert    = "INSERT"
+	Into      = "INTO"
 
 	InsertInto   = "INSERT INTO"
 	Values       = "VALUES"
@@ -46,4 +47,68 @@ const (
 	GreaterEqual = ">="
 	Less         = "<"
 	LessEqual    = "<="
+
+	Join      = "JOIN"
+	InnerJoin = "INNER JOIN"
+	LeftJoin  = "LEFT JOIN"
+	RightJoin = "RIGHT JOIN"
+	FullJoin  = "FULL JOIN"
+	CrossJoin = "CROSS JOIN"
+	On        = "ON"
+	Using     = "USING"
+
+	With          = "WITH"
+	WithRecursive = "WITH RECURSIVE"
+
+	Over               = "OVER"
+	Window             = "WINDOW"
+	PartitionBy        = "PARTITION BY"
+	Rows               = "ROWS"
+	Range              = "RANGE"
+	Preceding          = "PRECEDING"
+	Following          = "FOLLOWING"
+	CurrentRow         = "CURRENT ROW"
+	UnboundedPreceding = "UNBOUNDED PRECEDING"
+	UnboundedFollowing = "UNBOUNDED FOLLOWING"
+
+	OnDuplicateKeyUpdate = "ON DUPLICATE KEY UPDATE"
+	OnConflict           = "ON CONFLICT"
+	DoUpdateSet          = "DO UPDATE SET"
+	DoNothing            = "DO NOTHING"
+	Excluded             = "EXCLUDED"
+	MergeInto            = "MERGE INTO"
+	WhenMatched          = "WHEN MATCHED THEN UPDATE SET"
+	WhenNotMatched       = "WHEN NOT MATCHED THEN INSERT"
+
+	Returning = "RETURNING"
+	Output    = "OUTPUT"
+	Inserted  = "INSERTED"
+	Deleted   = "DELETED"
+
+	Case              = "CASE"
+	When              = "WHEN"
+	Then              = "THEN"
+	Else              = "ELSE"
+	End               = "END"
+	Cast              = "CAST"
+	Coalesce          = "COALESCE"
+	NullIf            = "NULLIF"
+	Null              = "NULL"
+	Is                = "IS"
+	IsNot             = "IS NOT"
+	IsDistinctFrom    = "IS DISTINCT FROM"
+	IsNotDistinctFrom = "IS NOT DISTINCT FROM"
+	Date              = "DATE"
+	Time              = "TIME"
+	Timestamp         = "TIMESTAMP"
+
+	ForUpdate  = "FOR UPDATE"
+	ForShare   = "FOR SHARE"
+	NoWait     = "NOWAIT"
+	SkipLocked = "SKIP LOCKED"
+
+	Union     = "UNION"
+	UnionAll  = "UNION ALL"
+	Intersect = "INTERSECT"
+	Except    = "EXCEPT"
 )
@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"strings"
+
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// LockExpr 行锁，例如：FOR UPDATE SKIP LOCKED
+//
+// 方言使用表提示(LockHint)时，格式化为FROM表之后的WITH (...)，例如SQLServer：WITH (UPDLOCK, ROWLOCK, READPAST)
+// 表提示仅作用于FROM的表，连接的表不加锁
+type LockExpr struct {
+	//Mode 锁模式：keywords.ForUpdate或keywords.ForShare
+	Mode string
+	//Wait 获取不到锁时的处理：keywords.NoWait或keywords.SkipLocked，为空时等待
+	Wait string
+}
+
+func (l *LockExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendKeyword(l.Mode)
+	if l.Wait != "" {
+		buffer.AppendString(keywords.Space).AppendKeyword(l.Wait)
+	}
+}
+
+// formatHint 格式化为表提示
+func (l *LockExpr) formatHint(buffer *TracedBuffer) {
+	var hints []string
+	if l.Mode == keywords.ForShare {
+		hints = append(hints, "HOLDLOCK", "ROWLOCK")
+	} else {
+		hints = append(hints, "UPDLOCK", "ROWLOCK")
+	}
+	switch l.Wait {
+	case keywords.NoWait:
+		hints = append(hints, "NOWAIT")
+	case keywords.SkipLocked:
+		hints = append(hints, "READPAST")
+	}
+	buffer.AppendKeywordWithSpace(keywords.With)
+	buffer.AppendString("(").AppendString(strings.Join(hints, ", ")).AppendString(")")
+}
+
+// Lock 设置行锁，mode为keywords.ForUpdate或keywords.ForShare，wait为可选的keywords.NoWait或keywords.SkipLocked
+func (s *SelectExpr) Lock(mode string, wait ...string) *SelectExpr {
+	s.LockExpr = &LockExpr{Mode: mode}
+	if len(wait) > 0 {
+		s.LockExpr.Wait = wait[0]
+	}
+	return s
+}
+
+// ForUpdate 使用排他锁：FOR UPDATE
+func (s *SelectExpr) ForUpdate() *SelectExpr {
+	return s.Lock(keywords.ForUpdate)
+}
+
+// ForShare 使用共享锁：FOR SHARE
+func (s *SelectExpr) ForShare() *SelectExpr {
+	return s.Lock(keywords.ForShare)
+}
+
+// NoWait 获取不到锁时立即返回错误，需要先设置ForUpdate或ForShare
+func (s *SelectExpr) NoWait() *SelectExpr {
+	if s.LockExpr != nil {
+		s.LockExpr.Wait = keywords.NoWait
+	}
+	return s
+}
+
+// SkipLocked 跳过已被锁定的行，需要先设置ForUpdate或ForShare
+func (s *SelectExpr) SkipLocked() *SelectExpr {
+	if s.LockExpr != nil {
+		s.LockExpr.Wait = keywords.SkipLocked
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr/keywords"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	newExpr := func() *SelectExpr {
+		return Select(All).From(N("job")).Where(Eq(N("status"), Const(0))).OrderBy(N("id")).Limit(10)
+	}
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    Expr
+		want    string
+	}{
+		{
+			name:    "for update(mysql)",
+			dialect: dialect.MySQL,
+			expr:    newExpr().ForUpdate(),
+			want:    "SELECT * FROM `job` WHERE `status` = 0 ORDER BY `id` LIMIT :limit OFFSET :offset FOR UPDATE",
+		}, {
+			name:    "skip locked(postgres)",
+			dialect: dialect.Postgres,
+			expr:    newExpr().ForUpdate().SkipLocked(),
+			want:    `SELECT * FROM "job" WHERE "status" = 0 ORDER BY "id" LIMIT :limit OFFSET :offset FOR UPDATE SKIP LOCKED`,
+		}, {
+			name:    "share nowait(postgres)",
+			dialect: dialect.Postgres,
+			expr:    newExpr().ForShare().NoWait(),
+			want:    `SELECT * FROM "job" WHERE "status" = 0 ORDER BY "id" LIMIT :limit OFFSET :offset FOR SHARE NOWAIT`,
+		}, {
+			name:    "skip locked(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    newExpr().ForUpdate().SkipLocked(),
+			want:    "SELECT * FROM [job] WITH (UPDLOCK, ROWLOCK, READPAST) WHERE [status] = 0 ORDER BY [id] OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY",
+		}, {
+			name:    "share(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    Select(All).From(N("job")).ForShare(),
+			want:    "SELECT * FROM [job] WITH (HOLDLOCK, ROWLOCK)",
+		}, {
+			name:    "filter",
+			dialect: dialect.MySQL,
+			expr: func() Expr {
+				s := Select(All).From(N("job"))
+				UseForUpdate(keywords.NoWait)(s)
+				return s
+			}(),
+			want: "SELECT * FROM `job` FOR UPDATE NOWAIT",
+		}, {
+			name:    "count without lock",
+			dialect: dialect.Postgres,
+			expr:    newExpr().ForUpdate().BuildCountExpr(),
+			want:    `SELECT COUNT(1) FROM "job" WHERE "status" = 0`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(tt.dialect)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
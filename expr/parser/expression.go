@@ -0,0 +1,638 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// niladic 不带括号的函数，例如：CURRENT_TIMESTAMP
+var niladic = map[string]bool{
+	"CURRENT_TIMESTAMP": true, "CURRENT_DATE": true, "CURRENT_TIME": true, "LOCALTIMESTAMP": true, "LOCALTIME": true,
+}
+
+// parseExpr 表达式，优先级从低到高：OR、AND、NOT、比较、加减、乘除、一元运算
+func (p *parser) parseExpr() (expr.Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (expr.Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	items := []expr.Expr{left}
+	for p.accept(keywords.Or) {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, right)
+	}
+	if len(items) == 1 {
+		return left, nil
+	}
+	return expr.Or(items...), nil
+}
+
+func (p *parser) parseAnd() (expr.Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	items := []expr.Expr{left}
+	for p.accept(keywords.And) {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, right)
+	}
+	if len(items) == 1 {
+		return left, nil
+	}
+	return expr.And(items...), nil
+}
+
+func (p *parser) parseNot() (expr.Expr, error) {
+	if p.accept(keywords.Not, keywords.Exists) {
+		query, err := p.parseSubQuery()
+		if err != nil {
+			return nil, err
+		}
+		return expr.NotExists(query), nil
+	}
+	if p.accept(keywords.Not) {
+		exp, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Not(exp), nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr.Expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	switch {
+	case t.typ == tokOp && (t.val == "=" || t.val == "!=" || t.val == "<>" || t.val == "<" || t.val == "<=" || t.val == ">" || t.val == ">="):
+		p.next()
+		if n := p.peek(); n.is("ANY") || n.is("ALL") || n.is("SOME") {
+			return nil, p.unsupported(n, "quantified comparison "+strings.ToUpper(n.val))
+		}
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Binary(left, t.val, right), nil
+	case t.is("<=>"):
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return expr.IsNotDistinctFrom(left, right), nil
+	case t.is(keywords.Is):
+		p.next()
+		not := p.accept(keywords.Not)
+		if p.accept(keywords.Null) {
+			if not {
+				return expr.IsNotNull(left), nil
+			}
+			return expr.IsNull(left), nil
+		}
+		if p.accept("DISTINCT", keywords.From) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if not {
+				return expr.IsNotDistinctFrom(left, right), nil
+			}
+			return expr.IsDistinctFrom(left, right), nil
+		}
+		return nil, p.unsupported(p.peek(), "IS "+p.peek().val)
+	}
+	not := false
+	if t.is(keywords.Not) && (p.peekAt(1).is(keywords.Like) || p.peekAt(1).is(keywords.In) || p.peekAt(1).is(keywords.Between)) {
+		p.next()
+		not = true
+	}
+	switch {
+	case p.accept(keywords.Like):
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if n := p.peek(); n.is("ESCAPE") {
+			return nil, p.unsupported(n, "LIKE ... ESCAPE")
+		}
+		if not {
+			return expr.Binary(left, keywords.Not+keywords.Space+keywords.Like, right), nil
+		}
+		return expr.Like(left, right), nil
+	case p.accept(keywords.In):
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		if p.peek().is(keywords.Select) || p.peek().is(keywords.With) {
+			p.pos--
+			query, err := p.parseSubQuery()
+			if err != nil {
+				return nil, err
+			}
+			if not {
+				return expr.NotInQuery(left, query), nil
+			}
+			return expr.InQuery(left, query), nil
+		}
+		values, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		if not {
+			return expr.NotInValues(left, values...), nil
+		}
+		return expr.InValues(left, values...), nil
+	case p.accept(keywords.Between):
+		start, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(keywords.And); err != nil {
+			return nil, err
+		}
+		end, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if not {
+			return expr.Not(expr.Between(left, start, end)), nil
+		}
+		return expr.Between(left, start, end), nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (expr.Expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.is("+") && !t.is("-") && !t.is("||") {
+			return left, nil
+		}
+		if t.is("||") && p.dialect != nil && !p.dialect.PipesConcat {
+			return nil, p.unsupported(t, "|| operator in "+p.dialect.Name)
+		}
+		p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = expr.Binary(left, t.val, right)
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr.Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if !t.is("*") && !t.is("/") && !t.is("%") {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = expr.Binary(left, t.val, right)
+	}
+}
+
+func (p *parser) parseUnary() (expr.Expr, error) {
+	if p.accept("+") {
+		return p.parseUnary()
+	}
+	if p.accept("-") {
+		if t := p.peek(); t.typ == tokNumber {
+			p.next()
+			return number("-" + t.val), nil
+		}
+		exp, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return expr.Unary("-", exp), nil
+	}
+	exp, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	//Postgres的类型转换：value::type
+	for p.accept("::") {
+		typeName, err := p.parseTypeName()
+		if err != nil {
+			return nil, err
+		}
+		exp = expr.Cast(exp, typeName)
+	}
+	return exp, nil
+}
+
+func (p *parser) parsePrimary() (expr.Expr, error) {
+	t := p.peek()
+	switch t.typ {
+	case tokNumber:
+		p.next()
+		return number(t.val), nil
+	case tokString:
+		p.next()
+		return expr.Const(t.val), nil
+	case tokParam:
+		p.next()
+		return p.param(t)
+	case tokQuoted:
+		return p.parseNameOrCall()
+	case tokOp:
+		switch t.val {
+		case "*":
+			p.next()
+			return expr.All, nil
+		case "(":
+			if n := p.peekAt(1); n.is(keywords.Select) || n.is(keywords.With) {
+				return p.parseSubQuery()
+			}
+			p.next()
+			items, err := p.parseExprList()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			if len(items) > 1 {
+				return expr.Paren(expr.List(keywords.Comma, items...)), nil
+			}
+			return expr.Paren(items[0]), nil
+		}
+		return nil, p.errorf(t, "unexpected %s", t)
+	case tokIdent:
+		upper := strings.ToUpper(t.val)
+		switch upper {
+		case keywords.Null:
+			p.next()
+			return expr.NULL, nil
+		case "TRUE", "FALSE":
+			p.next()
+			return expr.Const(upper == "TRUE"), nil
+		case keywords.Case:
+			return p.parseCase()
+		case keywords.Cast:
+			return p.parseCast()
+		case keywords.Exists:
+			p.next()
+			query, err := p.parseSubQuery()
+			if err != nil {
+				return nil, err
+			}
+			return expr.Exists(query), nil
+		case keywords.Date, keywords.Time, keywords.Timestamp:
+			//日期字面量：DATE '2023-01-01'
+			if n := p.peekAt(1); n.typ == tokString {
+				p.next()
+				p.next()
+				return literal(upper, n.val), nil
+			}
+		case "INTERVAL":
+			return nil, p.unsupported(t, "INTERVAL")
+		}
+		if niladic[upper] && !p.peekAt(1).is("(") {
+			p.next()
+			return expr.Raw(upper), nil
+		}
+		if reserved[upper] && !(p.duplicateKey && upper == keywords.Values && p.peekAt(1).is("(")) {
+			return nil, p.errorf(t, "unexpected %s", t)
+		}
+		return p.parseNameOrCall()
+	}
+	return nil, p.errorf(t, "unexpected %s", t)
+}
+
+// parseSubQuery (SELECT ...)
+func (p *parser) parseSubQuery() (*expr.SubQueryExpr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var with *expr.WithExpr
+	if p.peek().is(keywords.With) {
+		var err error
+		if with, err = p.parseWith(); err != nil {
+			return nil, err
+		}
+	}
+	query, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	if with != nil {
+		query.With(with)
+	}
+	return expr.SubQuery(query), p.expect(")")
+}
+
+// parseNameOrCall 列名(t.col、t.*)、函数调用和窗口函数
+func (p *parser) parseNameOrCall() (expr.Expr, error) {
+	t := p.peek()
+	if t.typ == tokIdent && p.peekAt(1).is("(") {
+		return p.parseCall()
+	}
+	var parts []string
+	for {
+		part := p.next()
+		if part.typ != tokIdent && part.typ != tokQuoted {
+			return nil, p.errorf(part, "expect identifier")
+		}
+		parts = append(parts, part.val)
+		if !p.accept(".") {
+			break
+		}
+		if p.accept("*") {
+			parts = append(parts, "*")
+			break
+		}
+	}
+	name := expr.Name(parts[len(parts)-1], parts[:len(parts)-1]...)
+	//upsert中引用待插入的值：EXCLUDED.col
+	if len(parts) == 2 && t.typ == tokIdent && strings.EqualFold(parts[0], keywords.Excluded) {
+		return expr.Excluded(expr.Name(name.Name)), nil
+	}
+	return name, nil
+}
+
+func (p *parser) parseCall() (expr.Expr, error) {
+	t := p.next()
+	name := strings.ToUpper(t.val)
+	p.next()
+	if n := p.peek(); n.is("DISTINCT") {
+		return nil, p.unsupported(n, name+"(DISTINCT ...)")
+	}
+	var args []expr.Expr
+	if !p.accept(")") {
+		var err error
+		if args, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	//MySQL的ON DUPLICATE KEY UPDATE中引用待插入的值：VALUES(col)
+	if p.duplicateKey && name == keywords.Values && len(args) == 1 {
+		return expr.Excluded(args[0]), nil
+	}
+	fn := expr.Fn(name, args...)
+	if !p.accept(keywords.Over) {
+		return fn, nil
+	}
+	if p.peek().is("(") {
+		window, err := p.parseWindowSpec()
+		if err != nil {
+			return nil, err
+		}
+		return fn.Over(window), nil
+	}
+	windowName, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	return fn.OverWindow(windowName), nil
+}
+
+// parseWindowSpec ([base] [PARTITION BY ...] [ORDER BY ...] [ROWS|RANGE ...])
+func (p *parser) parseWindowSpec() (*expr.WindowExpr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	window := expr.Window("")
+	if t := p.peek(); t.typ == tokQuoted || (t.typ == tokIdent && !reserved[strings.ToUpper(t.val)] && !t.is("PARTITION") && !t.is("ROWS") && !t.is("RANGE")) {
+		window.Base = p.next().val
+	}
+	if p.accept("PARTITION", "BY") {
+		items, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		window.PartitionBy(items...)
+	}
+	if p.accept("ORDER", "BY") {
+		items, err := p.parseOrderItems()
+		if err != nil {
+			return nil, err
+		}
+		window.OrderBy(items...)
+	}
+	if t := p.peek(); t.is(keywords.Rows) || t.is(keywords.Range) {
+		p.next()
+		frame := &expr.FrameExpr{Unit: strings.ToUpper(t.val)}
+		var err error
+		if p.accept(keywords.Between) {
+			if frame.Start, err = p.parseFrameBound(); err != nil {
+				return nil, err
+			}
+			if err = p.expect(keywords.And); err != nil {
+				return nil, err
+			}
+			if frame.End, err = p.parseFrameBound(); err != nil {
+				return nil, err
+			}
+		} else if frame.Start, err = p.parseFrameBound(); err != nil {
+			return nil, err
+		}
+		window.FrameExpr = frame
+	}
+	return window, p.expect(")")
+}
+
+func (p *parser) parseFrameBound() (string, error) {
+	switch {
+	case p.accept("UNBOUNDED", "PRECEDING"):
+		return keywords.UnboundedPreceding, nil
+	case p.accept("UNBOUNDED", "FOLLOWING"):
+		return keywords.UnboundedFollowing, nil
+	case p.accept("CURRENT", "ROW"):
+		return keywords.CurrentRow, nil
+	}
+	t := p.next()
+	n, err := strconv.Atoi(t.val)
+	if t.typ != tokNumber || err != nil {
+		return "", p.errorf(t, "expect frame bound")
+	}
+	if p.accept("PRECEDING") {
+		return expr.Preceding(n), nil
+	}
+	if p.accept("FOLLOWING") {
+		return expr.Following(n), nil
+	}
+	return "", p.errorf(p.peek(), "expect PRECEDING or FOLLOWING")
+}
+
+// parseCase CASE [value] WHEN ... THEN ... [ELSE ...] END
+func (p *parser) parseCase() (expr.Expr, error) {
+	p.next()
+	c := expr.Case()
+	if !p.peek().is(keywords.When) {
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c = expr.SimpleCase(value)
+	}
+	for p.accept(keywords.When) {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(keywords.Then); err != nil {
+			return nil, err
+		}
+		result, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.When(cond, result)
+	}
+	if len(c.Whens) == 0 {
+		return nil, p.errorf(p.peek(), "expect WHEN")
+	}
+	if p.accept(keywords.Else) {
+		result, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.Else(result)
+	}
+	return c, p.expect(keywords.End)
+}
+
+// parseCast CAST(value AS type)
+func (p *parser) parseCast() (expr.Expr, error) {
+	p.next()
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	value, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(keywords.AS); err != nil {
+		return nil, err
+	}
+	typeName, err := p.parseTypeName()
+	if err != nil {
+		return nil, err
+	}
+	return expr.Cast(value, typeName), p.expect(")")
+}
+
+// parseTypeName 类型名称，例如：INT、DOUBLE PRECISION、DECIMAL(10,2)、NVARCHAR(MAX)
+func (p *parser) parseTypeName() (string, error) {
+	var words []string
+	for p.peek().typ == tokIdent {
+		words = append(words, strings.ToUpper(p.next().val))
+	}
+	if len(words) == 0 {
+		return "", p.errorf(p.peek(), "expect type name")
+	}
+	typeName := strings.Join(words, " ")
+	if p.accept("(") {
+		var params []string
+		for {
+			t := p.next()
+			if t.typ != tokNumber && t.typ != tokIdent {
+				return "", p.errorf(t, "expect type parameter")
+			}
+			params = append(params, strings.ToUpper(t.val))
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return "", err
+		}
+		typeName += "(" + strings.Join(params, ",") + ")"
+	}
+	return typeName, nil
+}
+
+// param 参数，?按出现顺序命名为p1、p2...，$n命名为pn
+// 传入了参数值时，参数必须有对应的值，否则返回错误
+func (p *parser) param(t token) (*expr.ValueExpr, error) {
+	switch t.val[0] {
+	case '?':
+		p.paramIdx++
+		return p.positional(t, p.paramIdx)
+	case '$':
+		if n, err := strconv.Atoi(t.val[1:]); err == nil {
+			return p.positional(t, n)
+		}
+	}
+	name := t.val[1:]
+	value, ok := p.named[name]
+	if !ok && p.bound {
+		return nil, p.errorf(t, "missing argument %s", name)
+	}
+	return expr.Var(name, value), nil
+}
+
+// positional 第n个(从1开始)位置参数
+func (p *parser) positional(t token, n int) (*expr.ValueExpr, error) {
+	var value any
+	if n > 0 && n <= len(p.args) {
+		value = p.args[n-1]
+	} else if p.bound {
+		return nil, p.errorf(t, "missing argument %d, %d given", n, len(p.args))
+	}
+	if n > p.maxParam {
+		p.maxParam = n
+	}
+	return expr.Var(fmt.Sprintf("p%d", n), value), nil
+}
+
+func literal(typ string, value string) *expr.LiteralExpr {
+	switch typ {
+	case keywords.Date:
+		return expr.Date(value)
+	case keywords.Time:
+		return expr.Time(value)
+	}
+	return expr.Timestamp(value)
+}
+
+// number 整数使用常量，其他数字保持原始文本
+func number(val string) expr.Expr {
+	if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return expr.Const(n)
+	}
+	return expr.Raw(val)
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package parser
+
+import (
+	"fmt"
+	"github.com/gnodux/sqlmx/dialect"
+	"strings"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokEOF tokenType = iota
+	//tokIdent 标识符或关键字
+	tokIdent
+	//tokQuoted 使用`name`、"name"或[name]引用的标识符
+	tokQuoted
+	tokNumber
+	tokString
+	//tokParam 参数：?、$1、:name、@name
+	tokParam
+	//tokOp 运算符和标点
+	tokOp
+)
+
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+func (t token) String() string {
+	if t.typ == tokEOF {
+		return "end of input"
+	}
+	return t.val
+}
+
+// is 判断是否是指定的关键字或运算符(关键字不区分大小写)
+func (t token) is(val string) bool {
+	switch t.typ {
+	case tokIdent:
+		return strings.EqualFold(t.val, val)
+	case tokOp:
+		return t.val == val
+	}
+	return false
+}
+
+// lex 将SQL拆分为token，忽略空白和注释
+//
+// d为SQL所使用的方言，决定双引号的含义和字符串的转义方式，为nil时双引号表示标识符，反斜杠为转义字符
+func lex(sql string, d *dialect.Dialect) ([]token, error) {
+	backslash := d == nil || d.BackslashEscape
+	doubleQuoteString := d != nil && d.DoubleQuoteString
+	var tokens []token
+	runes := []rune(sql)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, &Error{Pos: start, Msg: "unterminated comment"}
+			}
+			i += 2
+		case c == '\'' || ((c == 'N' || c == 'n') && i+1 < len(runes) && runes[i+1] == '\''):
+			if c != '\'' {
+				i++
+			}
+			val, next, err := lexString(runes, i, backslash)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{typ: tokString, val: val, pos: start})
+			i = next
+		case c == '"' && doubleQuoteString:
+			val, next, err := lexString(runes, i, backslash)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{typ: tokString, val: val, pos: start})
+			i = next
+		case c == '`' || c == '"' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := i + 1
+			for end < len(runes) && runes[end] != closing {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, &Error{Pos: start, Msg: "unterminated quoted identifier"}
+			}
+			tokens = append(tokens, token{typ: tokQuoted, val: string(runes[i+1 : end]), pos: start})
+			i = end + 1
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{typ: tokNumber, val: string(runes[start:i]), pos: start})
+		case isIdentStart(c):
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{typ: tokIdent, val: string(runes[start:i]), pos: start})
+		case c == '?':
+			i++
+			tokens = append(tokens, token{typ: tokParam, val: "?", pos: start})
+		case (c == '$' || c == ':' || c == '@') && i+1 < len(runes) && isIdentPart(runes[i+1]):
+			i++
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{typ: tokParam, val: string(runes[start:i]), pos: start})
+		default:
+			op := string(c)
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "<=", ">=", "<>", "!=", "||", "::":
+					op = two
+				}
+				if two == "<=" && i+2 < len(runes) && runes[i+2] == '>' {
+					op = "<=>"
+				}
+			}
+			if !strings.Contains("=<>!|:+-*/%(),.;", string(c)) {
+				return nil, &Error{Pos: start, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			i += len([]rune(op))
+			tokens = append(tokens, token{typ: tokOp, val: op, pos: start})
+		}
+	}
+	tokens = append(tokens, token{typ: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// lexString 读取runes[i]引用的字符串，支持连续两个引号转义，backslash为true时支持反斜杠转义
+func lexString(runes []rune, i int, backslash bool) (string, int, error) {
+	start := i
+	quote := runes[i]
+	sb := strings.Builder{}
+	i++
+	for i < len(runes) {
+		switch runes[i] {
+		case '\\':
+			if backslash && i+1 < len(runes) {
+				sb.WriteRune(unescape(runes[i+1]))
+				i += 2
+				continue
+			}
+		case quote:
+			if i+1 < len(runes) && runes[i+1] == quote {
+				sb.WriteRune(quote)
+				i += 2
+				continue
+			}
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteRune(runes[i])
+		i++
+	}
+	return "", 0, &Error{Pos: start, Msg: "unterminated string"}
+}
+
+// unescape MySQL反斜杠转义序列对应的字符
+func unescape(c rune) rune {
+	switch c {
+	case '0':
+		return 0
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'Z':
+		return 0x1a
+	}
+	return c
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c)
+}
+
+func isIdentPart(c rune) bool {
+	return c == '_' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -0,0 +1,923 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+// Package parser 将SQL文本解析为expr表达式树
+//
+// 仅支持expr包能够表示的SQL子集：SELECT(WITH、JOIN、GROUP BY、HAVING、WINDOW、UNION等复合查询、ORDER BY、分页、行锁)、
+// INSERT(多行VALUES、INSERT ... SELECT、upsert、RETURNING)、UPDATE和DELETE。无法表示的语法返回包装了ErrUnsupported的*Error
+//
+// 标识符支持`name`、"name"和[name]三种引用方式，参数支持?、$1、:name和@name，解析后均为expr.ValueExpr，
+// 因此解析结果可以使用任意方言重新格式化，Translate在此基础上实现SQL的方言转换
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// ErrUnsupported SQL语法有效，但是无法使用expr表达式树表示
+var ErrUnsupported = errors.New("unsupported sql")
+
+// Error 解析错误，Pos为出错位置(字符偏移)
+type Error struct {
+	Pos  int
+	Near string
+	Msg  string
+	//Unsupported 为true时表示语法无法使用expr表示，errors.Is(err, ErrUnsupported)返回true
+	Unsupported bool
+}
+
+func (e *Error) Error() string {
+	if e.Near != "" {
+		return fmt.Sprintf("sql parse error at %d near %q: %s", e.Pos, e.Near, e.Msg)
+	}
+	return fmt.Sprintf("sql parse error at %d: %s", e.Pos, e.Msg)
+}
+
+func (e *Error) Unwrap() error {
+	if e.Unsupported {
+		return ErrUnsupported
+	}
+	return nil
+}
+
+// reserved 不能作为隐式别名的关键字
+var reserved = map[string]bool{
+	"FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"FETCH": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "JOIN": true, "INNER": true, "LEFT": true,
+	"RIGHT": true, "FULL": true, "CROSS": true, "OUTER": true, "ON": true, "USING": true, "FOR": true, "WINDOW": true,
+	"SET": true, "VALUES": true, "RETURNING": true, "OUTPUT": true, "WITH": true, "AS": true, "AND": true, "OR": true,
+	"NOT": true, "LOCK": true, "SELECT": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+}
+
+// Parse 将SQL语句解析为expr表达式树(*expr.SelectExpr、*expr.InsertExpr、*expr.UpdateExpr或*expr.DeleteExpr)
+//
+// args为可选的参数值：仅传入一个map[string]any时对应命名参数(:name、@name)，否则按顺序对应?和$n；
+// 传入参数值时参数的数量和名称必须与SQL语句一致
+func Parse(sql string, args ...any) (expr.Expr, error) {
+	return ParseDialect(sql, nil, args...)
+}
+
+// ParseDialect 使用方言d的词法规则解析SQL语句，例如MySQL中双引号表示字符串，Postgres中反斜杠不是转义字符
+//
+// d为nil时与Parse相同
+func ParseDialect(sql string, d *dialect.Dialect, args ...any) (expr.Expr, error) {
+	tokens, err := lex(sql, d)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, dialect: d, bound: len(args) > 0}
+	if len(args) == 1 {
+		if named, ok := args[0].(map[string]any); ok {
+			p.named = named
+			args = nil
+		}
+	}
+	p.args = args
+	exp, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	if p.maxParam < len(p.args) {
+		return nil, &Error{Pos: p.peek().pos, Msg: fmt.Sprintf("too many arguments, %d given, %d used", len(p.args), p.maxParam)}
+	}
+	return exp, nil
+}
+
+// ParseSelect 解析查询语句
+func ParseSelect(sql string, args ...any) (*expr.SelectExpr, error) {
+	exp, err := Parse(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := exp.(*expr.SelectExpr)
+	if !ok {
+		return nil, &Error{Msg: fmt.Sprintf("expect SELECT statement, got %T", exp)}
+	}
+	return s, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	//dialect 源SQL的方言，为nil时不区分方言
+	dialect *dialect.Dialect
+	args    []any
+	named   map[string]any
+	//bound 是否传入了参数值
+	bound bool
+	//paramIdx ?参数的序号
+	paramIdx int
+	//maxParam 使用的最大位置参数序号
+	maxParam int
+	//duplicateKey 正在解析ON DUPLICATE KEY UPDATE，VALUES(col)解析为expr.Excluded
+	duplicateKey bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept 当前token是指定的关键字或运算符时前进并返回true
+func (p *parser) accept(vals ...string) bool {
+	for idx, val := range vals {
+		if !p.peekAt(idx).is(val) {
+			return false
+		}
+	}
+	p.pos += len(vals)
+	return true
+}
+
+func (p *parser) expect(vals ...string) error {
+	for _, val := range vals {
+		if !p.accept(val) {
+			return p.errorf(p.peek(), "expect %s", val)
+		}
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...any) *Error {
+	return &Error{Pos: t.pos, Near: t.String(), Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unsupported(t token, what string) *Error {
+	return &Error{Pos: t.pos, Near: t.String(), Msg: what + " is not supported", Unsupported: true}
+}
+
+func (p *parser) parseStatement() (expr.Expr, error) {
+	var (
+		with *expr.WithExpr
+		stmt expr.Expr
+		err  error
+	)
+	if p.peek().is(keywords.With) {
+		if with, err = p.parseWith(); err != nil {
+			return nil, err
+		}
+	}
+	t := p.peek()
+	switch {
+	case t.is(keywords.Select) || t.is("("):
+		var s *expr.SelectExpr
+		if s, err = p.parseSelect(); err == nil {
+			if with != nil {
+				s.With(with)
+			}
+			stmt = s
+		}
+	case t.is(keywords.Insert):
+		if with != nil {
+			return nil, p.unsupported(t, "WITH ... INSERT")
+		}
+		stmt, err = p.parseInsert()
+	case t.is(keywords.Update):
+		var u *expr.UpdateExpr
+		if u, err = p.parseUpdate(); err == nil {
+			stmt = u.With(with)
+		}
+	case t.is(keywords.Delete):
+		var d *expr.DeleteExpr
+		if d, err = p.parseDelete(); err == nil {
+			stmt = d.With(with)
+		}
+	case t.typ == tokEOF:
+		return nil, p.errorf(t, "empty statement")
+	default:
+		return nil, p.unsupported(t, "statement "+strings.ToUpper(t.val))
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if t := p.peek(); t.typ != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return stmt, nil
+}
+
+// parseWith WITH [RECURSIVE] name [(cols)] AS (query), ...
+func (p *parser) parseWith() (*expr.WithExpr, error) {
+	p.next()
+	with := &expr.WithExpr{Recursive: p.accept("RECURSIVE")}
+	for {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		var columns []string
+		if p.accept("(") {
+			for {
+				col, err := p.parseIdent()
+				if err != nil {
+					return nil, err
+				}
+				columns = append(columns, col)
+				if !p.accept(",") {
+					break
+				}
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if err = p.expect(keywords.AS, "("); err != nil {
+			return nil, err
+		}
+		query, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		with.As(name, query, columns...)
+		if !p.accept(",") {
+			return with, nil
+		}
+	}
+}
+
+// parseSelect 解析完整的查询：复合查询、ORDER BY、分页和行锁
+func (p *parser) parseSelect() (*expr.SelectExpr, error) {
+	s, err := p.parseSelectCore()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op string
+		switch {
+		case p.accept("UNION", "ALL"):
+			op = keywords.UnionAll
+		case p.accept("UNION"):
+			op = keywords.Union
+		case p.accept("INTERSECT"):
+			op = keywords.Intersect
+		case p.accept("EXCEPT"):
+			op = keywords.Except
+		}
+		if op == "" {
+			break
+		}
+		query, err := p.parseSelectCore()
+		if err != nil {
+			return nil, err
+		}
+		s.Compound(op, query)
+	}
+	if p.accept("ORDER", "BY") {
+		//(SELECT NULL)是SQLServer分页时的默认排序，格式化时由方言自动添加
+		if !p.accept("(", "SELECT", "NULL", ")") {
+			items, err := p.parseOrderItems()
+			if err != nil {
+				return nil, err
+			}
+			s.OrderBy(items...)
+		}
+	}
+	if err = p.parsePagination(s); err != nil {
+		return nil, err
+	}
+	if err = p.parseLock(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parseSelectCore SELECT ... FROM ... WHERE ... GROUP BY ... HAVING ... WINDOW ...
+func (p *parser) parseSelectCore() (*expr.SelectExpr, error) {
+	if p.accept("(") {
+		s, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		return s, p.expect(")")
+	}
+	if err := p.expect(keywords.Select); err != nil {
+		return nil, err
+	}
+	s := expr.Select()
+	if t := p.peek(); t.is("DISTINCT") {
+		return nil, p.unsupported(t, "SELECT DISTINCT")
+	}
+	p.accept("ALL")
+	if p.accept("TOP") {
+		limit, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		s.Limit(limit)
+	}
+	columns, err := p.parseSelectItems()
+	if err != nil {
+		return nil, err
+	}
+	s.Select(columns...)
+	if t := p.peek(); !p.accept(keywords.From) {
+		return nil, p.unsupported(t, "SELECT without FROM")
+	}
+	from, lock, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	s.From(from)
+	s.LockExpr = lock
+	if err = p.parseJoins(s); err != nil {
+		return nil, err
+	}
+	if p.accept(keywords.Where) {
+		if s.WhereExpr, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("GROUP", "BY") {
+		items, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		s.GroupBy(expr.List(keywords.Comma, items...))
+	}
+	if p.accept(keywords.Having) {
+		if s.HavingExpr, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept(keywords.Window) {
+		for {
+			name, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(keywords.AS); err != nil {
+				return nil, err
+			}
+			window, err := p.parseWindowSpec()
+			if err != nil {
+				return nil, err
+			}
+			s.Window(name, window)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseSelectItems() ([]expr.Expr, error) {
+	var items []expr.Expr
+	for {
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if alias, ok, err := p.parseAlias(); err != nil {
+			return nil, err
+		} else if ok {
+			item = expr.Alias(item, alias)
+		}
+		items = append(items, item)
+		if !p.accept(",") {
+			return items, nil
+		}
+	}
+}
+
+// parseAlias [AS] alias
+func (p *parser) parseAlias() (string, bool, error) {
+	if p.accept(keywords.AS) {
+		alias, err := p.parseIdent()
+		return alias, err == nil, err
+	}
+	t := p.peek()
+	if t.typ == tokQuoted || (t.typ == tokIdent && !reserved[strings.ToUpper(t.val)]) {
+		p.next()
+		return t.val, true, nil
+	}
+	return "", false, nil
+}
+
+// parseTableRef 表名或派生表，以及SQLServer的表提示WITH (UPDLOCK, ...)
+func (p *parser) parseTableRef() (expr.Expr, *expr.LockExpr, error) {
+	var table expr.Expr
+	if p.accept("(") {
+		query, err := p.parseSelect()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, nil, err
+		}
+		alias, ok, err := p.parseAlias()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, nil, p.errorf(p.peek(), "derived table must have an alias")
+		}
+		table = query.As(alias)
+	} else {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, nil, err
+		}
+		table = name
+		if alias, ok, err := p.parseAlias(); err != nil {
+			return nil, nil, err
+		} else if ok {
+			table = expr.Alias(name, alias)
+		}
+	}
+	if !p.peek().is(keywords.With) || !p.peekAt(1).is("(") {
+		return table, nil, nil
+	}
+	p.pos += 2
+	var lock *expr.LockExpr
+	for {
+		t := p.next()
+		switch strings.ToUpper(t.val) {
+		case "UPDLOCK", "XLOCK":
+			lock = lockOf(lock, keywords.ForUpdate)
+		case "HOLDLOCK":
+			lock = lockOf(lock, keywords.ForShare)
+		case "READPAST":
+			lock = lockOf(lock, "")
+			lock.Wait = keywords.SkipLocked
+		case "NOWAIT":
+			lock = lockOf(lock, "")
+			lock.Wait = keywords.NoWait
+		case "ROWLOCK":
+		default:
+			return nil, nil, p.unsupported(t, "table hint "+t.val)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return table, lock, p.expect(")")
+}
+
+func lockOf(lock *expr.LockExpr, mode string) *expr.LockExpr {
+	if lock == nil {
+		lock = &expr.LockExpr{Mode: keywords.ForUpdate}
+	}
+	if mode != "" {
+		lock.Mode = mode
+	}
+	return lock
+}
+
+func (p *parser) parseJoins(s *expr.SelectExpr) error {
+	for {
+		var joinType string
+		switch {
+		case p.accept(","), p.accept("CROSS", "JOIN"):
+			joinType = keywords.CrossJoin
+		case p.accept("JOIN"), p.accept("INNER", "JOIN"):
+			joinType = keywords.InnerJoin
+		case p.accept("LEFT", "JOIN"), p.accept("LEFT", "OUTER", "JOIN"):
+			joinType = keywords.LeftJoin
+		case p.accept("RIGHT", "JOIN"), p.accept("RIGHT", "OUTER", "JOIN"):
+			joinType = keywords.RightJoin
+		case p.accept("FULL", "JOIN"), p.accept("FULL", "OUTER", "JOIN"):
+			joinType = keywords.FullJoin
+		default:
+			return nil
+		}
+		table, _, err := p.parseTableRef()
+		if err != nil {
+			return err
+		}
+		join := expr.Join(joinType, table, nil)
+		if p.accept(keywords.On) {
+			if join.OnExpr, err = p.parseExpr(); err != nil {
+				return err
+			}
+		} else if p.accept(keywords.Using, "(") {
+			for {
+				col, err := p.parseIdent()
+				if err != nil {
+					return err
+				}
+				join.UsingExprs = append(join.UsingExprs, expr.Name(col))
+				if !p.accept(",") {
+					break
+				}
+			}
+			if err = p.expect(")"); err != nil {
+				return err
+			}
+		}
+		s.Join(join)
+	}
+}
+
+func (p *parser) parseOrderItems() ([]expr.Expr, error) {
+	var items []expr.Expr
+	for {
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.accept(keywords.Desc) {
+			item = expr.Desc(item)
+		} else if p.accept(keywords.Asc) {
+			item = expr.Asc(item)
+		}
+		if t := p.peek(); t.is("NULLS") {
+			return nil, p.unsupported(t, "NULLS FIRST/LAST")
+		}
+		items = append(items, item)
+		if !p.accept(",") {
+			return items, nil
+		}
+	}
+}
+
+// parsePagination LIMIT n [OFFSET m]、LIMIT m, n、OFFSET m ROWS [FETCH NEXT n ROWS ONLY]
+func (p *parser) parsePagination(s *expr.SelectExpr) error {
+	if p.accept(keywords.Limit) {
+		limit, err := p.parseInt()
+		if err != nil {
+			return err
+		}
+		if p.accept(",") {
+			offset := limit
+			if limit, err = p.parseInt(); err != nil {
+				return err
+			}
+			s.Offset(offset)
+		}
+		s.Limit(limit)
+	}
+	if p.accept(keywords.Offset) {
+		offset, err := p.parseInt()
+		if err != nil {
+			return err
+		}
+		s.Offset(offset)
+		_ = p.accept("ROWS") || p.accept("ROW")
+	}
+	if p.accept("FETCH") {
+		if !p.accept("NEXT") && !p.accept("FIRST") {
+			return p.errorf(p.peek(), "expect NEXT or FIRST")
+		}
+		limit, err := p.parseInt()
+		if err != nil {
+			return err
+		}
+		if !p.accept("ROWS") && !p.accept("ROW") {
+			return p.errorf(p.peek(), "expect ROWS")
+		}
+		if err = p.expect("ONLY"); err != nil {
+			return err
+		}
+		s.Limit(limit)
+	}
+	return nil
+}
+
+// parseLock FOR UPDATE|FOR SHARE [NOWAIT|SKIP LOCKED]、LOCK IN SHARE MODE
+func (p *parser) parseLock(s *expr.SelectExpr) error {
+	switch {
+	case p.accept("FOR", "UPDATE"):
+		s.ForUpdate()
+	case p.accept("FOR", "SHARE"):
+		s.ForShare()
+	case p.accept("LOCK", "IN", "SHARE", "MODE"):
+		s.ForShare()
+		return nil
+	default:
+		return nil
+	}
+	if t := p.peek(); t.is("OF") {
+		return p.unsupported(t, "FOR UPDATE OF")
+	}
+	if p.accept("NOWAIT") {
+		s.NoWait()
+	} else if p.accept("SKIP", "LOCKED") {
+		s.SkipLocked()
+	}
+	return nil
+}
+
+// parseInt 分页参数：整数常量，或者已绑定整数值的参数
+func (p *parser) parseInt() (int, error) {
+	t := p.next()
+	switch t.typ {
+	case tokNumber:
+		if n, err := strconv.Atoi(t.val); err == nil {
+			return n, nil
+		}
+	case tokParam:
+		param, err := p.param(t)
+		if err != nil {
+			return 0, err
+		}
+		switch n := param.Value.(type) {
+		case int:
+			return n, nil
+		case int64:
+			return int(n), nil
+		case int32:
+			return int(n), nil
+		}
+		return 0, &Error{Pos: t.pos, Near: t.val, Msg: "pagination parameter must be bound to an integer value", Unsupported: true}
+	}
+	return 0, p.errorf(t, "expect integer")
+}
+
+// parseInsert INSERT INTO table (cols) VALUES (...),(...) | SELECT ...
+func (p *parser) parseInsert() (*expr.InsertExpr, error) {
+	if err := p.expect(keywords.Insert, keywords.Into); err != nil {
+		return nil, err
+	}
+	table, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	ins := expr.InsertInto(table)
+	if t := p.peek(); !p.accept("(") {
+		return nil, p.unsupported(t, "INSERT without column list")
+	}
+	var cols []expr.Expr
+	for {
+		col, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, expr.Name(col))
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err = p.expect(")"); err != nil {
+		return nil, err
+	}
+	ins.Columns(cols...)
+	if ins.ReturningExprs, err = p.parseOutput(); err != nil {
+		return nil, err
+	}
+	if p.accept(keywords.Values) {
+		for {
+			if err = p.expect("("); err != nil {
+				return nil, err
+			}
+			row, err := p.parseExprList()
+			if err != nil {
+				return nil, err
+			}
+			if len(row) != len(cols) {
+				return nil, p.errorf(p.peek(), "expect %d values, got %d", len(cols), len(row))
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			ins.Row(row...)
+			if !p.accept(",") {
+				break
+			}
+		}
+	} else {
+		var query expr.Expr
+		if p.peek().is(keywords.With) {
+			with, err := p.parseWith()
+			if err != nil {
+				return nil, err
+			}
+			s, err := p.parseSelect()
+			if err != nil {
+				return nil, err
+			}
+			query = s.With(with)
+		} else if query, err = p.parseSelect(); err != nil {
+			return nil, err
+		}
+		ins.Select(query)
+	}
+	switch {
+	case p.accept("ON", "DUPLICATE", "KEY", "UPDATE"):
+		p.duplicateKey = true
+		assignments, err := p.parseAssignments()
+		p.duplicateKey = false
+		if err != nil {
+			return nil, err
+		}
+		ins.OnConflict().DoUpdate(assignments...)
+	case p.accept("ON", "CONFLICT"):
+		var conflict []expr.Expr
+		if t := p.peek(); t.is("ON") {
+			return nil, p.unsupported(t, "ON CONFLICT ON CONSTRAINT")
+		}
+		if p.accept("(") {
+			if conflict, err = p.parseExprList(); err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		ins.OnConflict(conflict...)
+		if p.accept("DO", "NOTHING") {
+			ins.DoNothing()
+		} else if err = p.expect("DO", "UPDATE", "SET"); err != nil {
+			return nil, err
+		} else {
+			assignments, err := p.parseAssignments()
+			if err != nil {
+				return nil, err
+			}
+			ins.DoUpdate(assignments...)
+			if t := p.peek(); t.is(keywords.Where) {
+				return nil, p.unsupported(t, "ON CONFLICT DO UPDATE ... WHERE")
+			}
+		}
+	}
+	if p.accept(keywords.Returning) {
+		if ins.ReturningExprs, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+	return ins, nil
+}
+
+// parseUpdate UPDATE table SET col = value, ... [WHERE ...]
+func (p *parser) parseUpdate() (*expr.UpdateExpr, error) {
+	p.next()
+	table, _, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(keywords.Set); err != nil {
+		return nil, err
+	}
+	assignments, err := p.parseAssignments()
+	if err != nil {
+		return nil, err
+	}
+	u := expr.Update(table)
+	for _, assignment := range assignments {
+		u.Values = append(u.Values, assignment)
+	}
+	if u.ReturningExprs, err = p.parseOutput(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.is(keywords.From) {
+		return nil, p.unsupported(t, "UPDATE ... FROM")
+	}
+	if p.accept(keywords.Where) {
+		if u.WhereExpr, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept(keywords.Returning) {
+		if u.ReturningExprs, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// parseDelete DELETE FROM table [WHERE ...]
+func (p *parser) parseDelete() (*expr.DeleteExpr, error) {
+	p.next()
+	if t := p.peek(); !p.accept(keywords.From) {
+		return nil, p.unsupported(t, "DELETE without FROM")
+	}
+	table, _, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	d := expr.Delete(table)
+	if d.ReturningExprs, err = p.parseOutput(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.is(keywords.Using) || t.is(keywords.From) {
+		return nil, p.unsupported(t, "multi-table DELETE")
+	}
+	if p.accept(keywords.Where) {
+		if d.WhereExpr, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept(keywords.Returning) {
+		if d.ReturningExprs, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// parseOutput SQLServer的OUTPUT INSERTED.col, DELETED.col
+func (p *parser) parseOutput() ([]expr.Expr, error) {
+	if !p.accept(keywords.Output) {
+		return nil, nil
+	}
+	var cols []expr.Expr
+	for {
+		t := p.next()
+		if !t.is(keywords.Inserted) && !t.is(keywords.Deleted) {
+			return nil, p.errorf(t, "expect INSERTED or DELETED")
+		}
+		if err := p.expect("."); err != nil {
+			return nil, err
+		}
+		if p.accept("*") {
+			cols = append(cols, expr.All)
+		} else {
+			col, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, expr.Name(col))
+		}
+		if !p.accept(",") {
+			return cols, nil
+		}
+	}
+}
+
+// parseAssignments col = value, ...
+func (p *parser) parseAssignments() ([]*expr.BinaryExpr, error) {
+	var assignments []*expr.BinaryExpr
+	for {
+		col, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(keywords.Equal); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, expr.Binary(col, keywords.Equal, value))
+		if !p.accept(",") {
+			return assignments, nil
+		}
+	}
+}
+
+func (p *parser) parseExprList() ([]expr.Expr, error) {
+	var exps []expr.Expr
+	for {
+		exp, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, exp)
+		if !p.accept(",") {
+			return exps, nil
+		}
+	}
+}
+
+// parseIdent 单个标识符(可以是引用的标识符)
+func (p *parser) parseIdent() (string, error) {
+	t := p.next()
+	if t.typ == tokQuoted || (t.typ == tokIdent && !reserved[strings.ToUpper(t.val)]) {
+		return t.val, nil
+	}
+	return "", p.errorf(t, "expect identifier")
+}
+
+// parseName 可能带有限定名称的标识符：schema.table、t.col
+func (p *parser) parseName() (*expr.NameExpr, error) {
+	var parts []string
+	for {
+		part, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+		if !p.accept(".") {
+			break
+		}
+	}
+	return expr.Name(parts[len(parts)-1], parts[:len(parts)-1]...), nil
+}
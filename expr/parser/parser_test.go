@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+func format(d *dialect.Dialect, exp expr.Expr) string {
+	buf := expr.NewTracedBuffer(d)
+	exp.Format(buf)
+	return buf.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		dialect *dialect.Dialect
+		want    string
+	}{
+		{
+			name:    "select",
+			sql:     "select id, name AS n, u.* from `user` u where age >= 18 and (name like 'a%' or role in ('admin', 'root')) order by id desc limit 10 offset 20",
+			dialect: dialect.MySQL,
+			want:    "SELECT `id`,`name` AS `n`,`u`.* FROM `user` AS `u` WHERE `age` >= 18 AND ( `name` LIKE 'a%' OR `role` IN ( 'admin','root' ) ) ORDER BY `id` DESC LIMIT :limit OFFSET :offset",
+		}, {
+			name:    "to postgres",
+			sql:     "SELECT * FROM `user` WHERE `id` = :id AND name IS NOT NULL LIMIT 5",
+			dialect: dialect.Postgres,
+			want:    `SELECT * FROM "user" WHERE "id" = :id AND "name" IS NOT NULL LIMIT :limit OFFSET :offset`,
+		}, {
+			name:    "join and group",
+			sql:     `SELECT r.name, COUNT(*) AS total FROM "user" AS u LEFT OUTER JOIN role r ON u.role_id = r.id JOIN dept USING (dept_id) WHERE u.age BETWEEN 1 AND 2 GROUP BY r.name HAVING COUNT(*) > 1`,
+			dialect: dialect.MySQL,
+			want:    "SELECT `r`.`name`,COUNT(*) AS `total` FROM `user` AS `u` LEFT JOIN `role` AS `r` ON `u`.`role_id` = `r`.`id` INNER JOIN `dept` USING ( `dept_id` ) WHERE `u`.`age` BETWEEN 1 AND 2 GROUP BY `r`.`name` HAVING COUNT(*) > 1",
+		}, {
+			name:    "subquery and exists",
+			sql:     "SELECT id FROM [user] WHERE dept_id IN (SELECT id FROM dept WHERE name <> N'x') AND NOT EXISTS (SELECT 1 FROM ban WHERE ban.uid = [user].id)",
+			dialect: dialect.SQLServer,
+			want:    "SELECT [id] FROM [user] WHERE [dept_id] IN (SELECT [id] FROM [dept] WHERE [name] <> 'x') AND NOT EXISTS (SELECT 1 FROM [ban] WHERE [ban].[uid] = [user].[id])",
+		}, {
+			name:    "with and union",
+			sql:     "WITH t AS (SELECT id FROM a) SELECT id FROM t UNION ALL SELECT id FROM b ORDER BY id",
+			dialect: dialect.MySQL,
+			want:    "WITH `t` AS (SELECT `id` FROM `a`) SELECT `id` FROM `t` UNION ALL SELECT `id` FROM `b` ORDER BY `id`",
+		}, {
+			name:    "case cast window",
+			sql:     "SELECT CASE WHEN age > 18 THEN 'adult' ELSE 'child' END AS stage, CAST(age AS DECIMAL(10,2)), price::int, ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary DESC) FROM emp",
+			dialect: dialect.Postgres,
+			want:    `SELECT CASE WHEN "age" > 18 THEN 'adult' ELSE 'child' END AS "stage",CAST("age" AS NUMERIC(10,2)),CAST("price" AS INTEGER),ROW_NUMBER() OVER (PARTITION BY "dept" ORDER BY "salary" DESC) FROM "emp"`,
+		}, {
+			name:    "sql server pagination and lock",
+			sql:     "SELECT * FROM [job] WITH (UPDLOCK, ROWLOCK, READPAST) WHERE [status] = 0 ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY",
+			dialect: dialect.Postgres,
+			want:    `SELECT * FROM "job" WHERE "status" = 0 LIMIT :limit OFFSET :offset FOR UPDATE SKIP LOCKED`,
+		}, {
+			name:    "insert rows",
+			sql:     "INSERT INTO user (name, age) VALUES ('a', 1), ('b', -2)",
+			dialect: dialect.SQLServer,
+			want:    "INSERT INTO [user] ( [name],[age] ) VALUES ( 'a',1 ),( 'b',-2 )",
+		}, {
+			name:    "upsert",
+			sql:     "INSERT INTO `user` (`id`, `name`) VALUES (:id, :name) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+			dialect: dialect.MySQL,
+			want:    "INSERT INTO `user` ( `id`,`name` ) VALUES ( :id,:name ) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		}, {
+			name:    "upsert to mysql",
+			sql:     `INSERT INTO "user" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+			dialect: dialect.MySQL,
+			want:    "INSERT INTO `user` ( `id`,`name` ) VALUES ( :p1,:p2 ) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		}, {
+			name:    "upsert returning",
+			sql:     `INSERT INTO "user" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" RETURNING "id"`,
+			dialect: dialect.Postgres,
+			want:    `INSERT INTO "user" ( "id","name" ) VALUES ( :p1,:p2 ) ON CONFLICT ( "id" ) DO UPDATE SET "name" = EXCLUDED."name" RETURNING "id"`,
+		}, {
+			name:    "insert select",
+			sql:     "INSERT INTO archive (id) SELECT id FROM user WHERE deleted = TRUE",
+			dialect: dialect.MySQL,
+			want:    "INSERT INTO `archive` ( `id` ) SELECT `id` FROM `user` WHERE `deleted` = TRUE",
+		}, {
+			name:    "update",
+			sql:     "UPDATE user SET name = ?, age = age + 1 OUTPUT INSERTED.id WHERE id = ?",
+			dialect: dialect.Postgres,
+			want:    `UPDATE "user" SET "name" = :p1, "age" = "age" + 1 WHERE "id" = :p2 RETURNING "id"`,
+		}, {
+			name:    "delete",
+			sql:     "-- remove\nDELETE FROM user /* all */ WHERE id IS DISTINCT FROM 1;",
+			dialect: dialect.MySQL,
+			want:    "DELETE FROM `user` WHERE NOT ( `id` <=> 1 )",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := Parse(tt.sql)
+			assert.NoError(t, err)
+			if err == nil {
+				assert.Equal(t, tt.want, format(tt.dialect, exp))
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	s, err := ParseSelect("SELECT * FROM user WHERE name = ? AND age > ? LIMIT ?", "a", 18, 10)
+	assert.NoError(t, err)
+	query, args, err := expr.NewTracedBuffer(dialect.Postgres).Build(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "user" WHERE "name" = $1 AND "age" > $2 LIMIT $3 OFFSET $4`, query)
+	assert.Equal(t, []any{"a", 18, 10, 0}, args)
+
+	exp, err := Parse("DELETE FROM user WHERE id = @id", map[string]any{"id": 1})
+	assert.NoError(t, err)
+	_, named, err := expr.NewTracedBuffer(dialect.MySQL).BuildNamed(exp)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": 1}, named)
+}
+
+func TestParseArgsMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		args []any
+	}{
+		{name: "too few", sql: "SELECT * FROM user WHERE name = ? AND age > ?", args: []any{"a"}},
+		{name: "too few numbered", sql: "SELECT * FROM user WHERE name = $1 AND age > $3", args: []any{"a", 18}},
+		{name: "too many", sql: "SELECT * FROM user WHERE name = ?", args: []any{"a", 18}},
+		{name: "missing name", sql: "SELECT * FROM user WHERE name = :name AND age > :age", args: []any{map[string]any{"name": "a"}}},
+		{name: "positional for named", sql: "SELECT * FROM user WHERE name = :name", args: []any{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.sql, tt.args...)
+			var parseErr *Error
+			assert.True(t, errors.As(err, &parseErr), "%v", err)
+			assert.False(t, errors.Is(err, ErrUnsupported))
+		})
+	}
+	//未传入参数值时不检查参数
+	_, err := Parse("SELECT * FROM user WHERE name = ? AND age > :age")
+	assert.NoError(t, err)
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		unsupported bool
+	}{
+		{name: "distinct", sql: "SELECT DISTINCT id FROM user", unsupported: true},
+		{name: "no from", sql: "SELECT 1", unsupported: true},
+		{name: "merge", sql: "MERGE INTO t USING s ON t.id = s.id", unsupported: true},
+		{name: "limit param", sql: "SELECT id FROM user LIMIT :limit", unsupported: true},
+		{name: "update from", sql: "UPDATE t SET a = 1 FROM s", unsupported: true},
+		{name: "missing paren", sql: "SELECT id FROM user WHERE id IN (1, 2"},
+		{name: "trailing", sql: "SELECT id FROM user WHERE id = 1 2"},
+		{name: "string", sql: "SELECT id FROM user WHERE name = 'a"},
+		{name: "empty", sql: "  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.sql)
+			assert.Error(t, err)
+			var parseErr *Error
+			assert.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, tt.unsupported, errors.Is(err, ErrUnsupported), err.Error())
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package parser
+
+import (
+	"fmt"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+)
+
+const concat = "CONCAT"
+
+// Translate 将方言from的SQL语句转换为方言to的SQL语句，返回位置参数形式的SQL和参数值
+//
+// 标识符引用、分页、布尔字面量、日期字面量和参数占位符均按照目标方言重新生成，例如：
+//
+//	Translate("SELECT * FROM `user` WHERE `active` = TRUE LIMIT 10, 20", dialect.MySQL, dialect.Postgres)
+//	// SELECT * FROM "user" WHERE "active" = TRUE LIMIT $1 OFFSET $2
+//
+// 无法在目标方言中表达的语句(例如没有冲突检测列的ON DUPLICATE KEY UPDATE转换为Postgres)返回包装了ErrUnsupported的错误，
+// 目标方言中||不是字符串连接运算符时，a || b转换为CONCAT(a, b)
+//
+// 使用expr构建的语句与方言无关，使用目标方言的TracedBuffer格式化即可：expr.NewTracedBuffer(to).Build(exp)
+func Translate(sql string, from, to *dialect.Dialect, args ...any) (string, []any, error) {
+	exp, err := ParseDialect(sql, from, args...)
+	if err == nil {
+		err = checkDialect(exp, to)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return expr.NewTracedBuffer(to).Build(rewriteConcat(exp, to))
+}
+
+// TranslateNamed 与Translate相同，返回命名参数形式的SQL和参数值，位置参数按顺序命名为p1、p2...
+func TranslateNamed(sql string, from, to *dialect.Dialect, args ...any) (string, map[string]any, error) {
+	exp, err := ParseDialect(sql, from, args...)
+	if err == nil {
+		err = checkDialect(exp, to)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return expr.NewTracedBuffer(to).BuildNamed(rewriteConcat(exp, to))
+}
+
+// checkDialect 检查语句能否在目标方言中表达，无法转换时返回包装了ErrUnsupported的错误：
+// 1. 没有冲突检测列的upsert(ON DUPLICATE KEY UPDATE)只能用于MySQL，ON CONFLICT和MERGE需要明确的冲突检测列
+// 2. RETURNING/OUTPUT需要目标方言支持
+func checkDialect(exp expr.Expr, to *dialect.Dialect) (err error) {
+	expr.Walk(exp, func(e expr.Expr) bool {
+		var returning []expr.Expr
+		switch stmt := e.(type) {
+		case *expr.InsertExpr:
+			if stmt.IsUpsert() && len(stmt.ConflictColumns) == 0 && to.Upsert != dialect.UpsertOnDuplicateKey {
+				err = fmt.Errorf("%w: upsert without conflict columns in %s", ErrUnsupported, to.Name)
+				return false
+			}
+			returning = stmt.ReturningExprs
+		case *expr.UpdateExpr:
+			returning = stmt.ReturningExprs
+		case *expr.DeleteExpr:
+			returning = stmt.ReturningExprs
+		}
+		if len(returning) > 0 && !to.SupportReturning() {
+			err = fmt.Errorf("%w: returning in %s", ErrUnsupported, to.Name)
+		}
+		return err == nil
+	})
+	return
+}
+
+// rewriteConcat 目标方言中||不是字符串连接运算符时，将a || b || c转换为CONCAT(a, b, c)
+func rewriteConcat(exp expr.Expr, to *dialect.Dialect) expr.Expr {
+	if to.PipesConcat {
+		return exp
+	}
+	return expr.Rewrite(exp, func(e expr.Expr) expr.Expr {
+		b, ok := e.(*expr.BinaryExpr)
+		if !ok || b.Operator != "||" {
+			return e
+		}
+		var args []expr.Expr
+		//子节点已经改写，左侧连续的||已经转换为CONCAT
+		if left, ok := b.Left.(*expr.FuncExpr); ok && left.Name == concat {
+			args = append(args, left.Args...)
+		} else {
+			args = append(args, b.Left)
+		}
+		return expr.Fn(concat, append(args, b.Right)...)
+	})
+}
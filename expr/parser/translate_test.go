@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		args     []any
+		from     *dialect.Dialect
+		to       *dialect.Dialect
+		want     string
+		wantArgs []any
+	}{
+		{
+			name:     "mysql to postgres",
+			sql:      "SELECT * FROM `user` WHERE `active` = TRUE AND `name` = ? LIMIT 10, 20",
+			args:     []any{"tom"},
+			from:     dialect.MySQL,
+			to:       dialect.Postgres,
+			want:     `SELECT * FROM "user" WHERE "active" = TRUE AND "name" = $1 LIMIT $2 OFFSET $3`,
+			wantArgs: []any{"tom", 20, 10},
+		}, {
+			name:     "mysql to sqlserver",
+			sql:      "SELECT id FROM `user` WHERE `active` = FALSE AND created_at > DATE '2023-01-01' LIMIT 10",
+			from:     dialect.MySQL,
+			to:       dialect.SQLServer,
+			want:     "SELECT [id] FROM [user] WHERE [active] = 0 AND [created_at] > CAST('2023-01-01' AS DATE) ORDER BY (SELECT NULL) OFFSET ? ROWS FETCH NEXT ? ROWS ONLY",
+			wantArgs: []any{0, 10},
+		}, {
+			name:     "mysql strings",
+			sql:      `UPDATE t SET name = "O'Brien", path = 'C:\\dir' WHERE id = ?`,
+			args:     []any{1},
+			from:     dialect.MySQL,
+			to:       dialect.Postgres,
+			want:     `UPDATE "t" SET "name" = 'O''Brien', "path" = 'C:\dir' WHERE "id" = $1`,
+			wantArgs: []any{1},
+		}, {
+			name:     "postgres to mysql",
+			sql:      `SELECT "name" FROM "t" WHERE "p" = 'C:\dir' AND "ts" < TIMESTAMP '2023-01-01 00:00:00' AND "id" = $1 OFFSET 5 ROWS FETCH NEXT 10 ROWS ONLY`,
+			args:     []any{7},
+			from:     dialect.Postgres,
+			to:       dialect.MySQL,
+			want:     "SELECT `name` FROM `t` WHERE `p` = 'C:\\\\dir' AND `ts` < TIMESTAMP '2023-01-01 00:00:00' AND `id` = ? LIMIT ? OFFSET ?",
+			wantArgs: []any{7, 10, 5},
+		}, {
+			name:     "sqlserver to postgres",
+			sql:      "SELECT TOP 5 [id] FROM [user] WITH (UPDLOCK, ROWLOCK) WHERE [id] > @id",
+			args:     []any{map[string]any{"id": 3}},
+			from:     dialect.SQLServer,
+			to:       dialect.Postgres,
+			want:     `SELECT "id" FROM "user" WHERE "id" > $1 LIMIT $2 OFFSET $3 FOR UPDATE`,
+			wantArgs: []any{3, 5, 0},
+		}, {
+			name:     "concat to mysql",
+			sql:      `SELECT "first" || ' ' || "last" AS "name" FROM "user" WHERE "id" = $1`,
+			args:     []any{1},
+			from:     dialect.Postgres,
+			to:       dialect.MySQL,
+			want:     "SELECT CONCAT(`first`,' ',`last`) AS `name` FROM `user` WHERE `id` = ?",
+			wantArgs: []any{1},
+		}, {
+			name:     "concat to sqlserver",
+			sql:      `SELECT "id" FROM "user" WHERE "code" = 'A' || $1`,
+			args:     []any{"1"},
+			from:     dialect.Postgres,
+			to:       dialect.SQLServer,
+			want:     "SELECT [id] FROM [user] WHERE [code] = CONCAT('A',?)",
+			wantArgs: []any{"1"},
+		}, {
+			name: "concat to postgres",
+			sql:  `SELECT "first" || "last" FROM "user"`,
+			from: dialect.Postgres,
+			to:   dialect.Postgres,
+			want: `SELECT "first" || "last" FROM "user"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := Translate(tt.sql, tt.from, tt.to, tt.args...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestTranslateUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		from *dialect.Dialect
+		to   *dialect.Dialect
+	}{
+		{
+			name: "upsert without conflict columns to postgres",
+			sql:  "INSERT INTO `user` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+			from: dialect.MySQL,
+			to:   dialect.Postgres,
+		}, {
+			name: "upsert without conflict columns to sqlserver",
+			sql:  "INSERT INTO `user` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+			from: dialect.MySQL,
+			to:   dialect.SQLServer,
+		}, {
+			name: "returning to mysql",
+			sql:  `INSERT INTO "user" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" RETURNING "id"`,
+			from: dialect.Postgres,
+			to:   dialect.MySQL,
+		}, {
+			name: "pipes in mysql",
+			sql:  "SELECT `a` || `b` FROM `t` WHERE `id` = ? AND `name` = ?",
+			from: dialect.MySQL,
+			to:   dialect.Postgres,
+		}, {
+			name: "output to mysql",
+			sql:  "DELETE FROM [user] OUTPUT DELETED.[id] WHERE [id] = ? AND [name] = ?",
+			from: dialect.SQLServer,
+			to:   dialect.MySQL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Translate(tt.sql, tt.from, tt.to, 1, "tom")
+			assert.ErrorIs(t, err, ErrUnsupported)
+			_, _, err = TranslateNamed(tt.sql, tt.from, tt.to, 1, "tom")
+			assert.ErrorIs(t, err, ErrUnsupported)
+		})
+	}
+}
+
+func TestTranslateNamed(t *testing.T) {
+	got, args, err := TranslateNamed("SELECT * FROM `user` WHERE `id` = ? AND `active` = TRUE", dialect.MySQL, dialect.SQLServer, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM [user] WHERE [id] = @p1 AND [active] = 1", got)
+	assert.Equal(t, map[string]any{"p1": 1}, args)
+}
+
+func TestTranslateArgsMismatch(t *testing.T) {
+	_, _, err := Translate("SELECT * FROM `user` WHERE `id` = ? AND `name` = ?", dialect.MySQL, dialect.Postgres, 1)
+	var parseErr *Error
+	assert.ErrorAs(t, err, &parseErr)
+	_, _, err = Translate("SELECT * FROM `user` WHERE `id` = ?", dialect.MySQL, dialect.Postgres, 1, "tom")
+	assert.ErrorAs(t, err, &parseErr)
+	_, _, err = TranslateNamed("SELECT * FROM [user] WHERE [id] = @id", dialect.SQLServer, dialect.MySQL, map[string]any{"uid": 1})
+	assert.ErrorAs(t, err, &parseErr)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"fmt"
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// Returning 设置插入后返回的列(Postgres: RETURNING，SQLServer: OUTPUT INSERTED)，方言不支持时忽略
+func (i *InsertExpr) Returning(cols ...Expr) *InsertExpr {
+	i.ReturningExprs = cols
+	return i
+}
+
+// Returning 设置更新后返回的列(Postgres: RETURNING，SQLServer: OUTPUT INSERTED)，方言不支持时忽略
+func (u *UpdateExpr) Returning(cols ...Expr) *UpdateExpr {
+	u.ReturningExprs = cols
+	return u
+}
+
+// Returning 设置删除的数据返回的列(Postgres: RETURNING，SQLServer: OUTPUT DELETED)，方言不支持时忽略
+func (d *DeleteExpr) Returning(cols ...Expr) *DeleteExpr {
+	d.ReturningExprs = cols
+	return d
+}
+
+// formatOutput 格式化SQLServer的OUTPUT子句，prefix为keywords.Inserted或keywords.Deleted
+func formatOutput(buf *TracedBuffer, prefix string, cols []Expr) {
+	if buf.Returning != dialect.ReturningOutput || len(cols) == 0 {
+		return
+	}
+	buf.AppendKeywordWithSpace(keywords.Output)
+	for idx, col := range cols {
+		if idx > 0 {
+			buf.AppendString(keywords.Comma)
+		}
+		buf.AppendKeyword(prefix).AppendString(".")
+		if raw, ok := col.(*RawExpr); ok {
+			buf.AppendString(fmt.Sprintf("%v", raw.Value))
+		} else {
+			buf.AppendString(buf.SQLNameFunc(exprName(col)))
+		}
+	}
+}
+
+// formatReturning 格式化语句末尾的RETURNING子句(Postgres)
+func formatReturning(buf *TracedBuffer, cols []Expr) {
+	if buf.Returning != dialect.ReturningClause || len(cols) == 0 {
+		return
+	}
+	buf.AppendKeywordWithSpace(keywords.Returning)
+	List(keywords.Comma, cols...).Format(buf)
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReturning(t *testing.T) {
+	newInsert := func() *InsertExpr {
+		return InsertInto(N("user"), N("name").Eq(Var("name", "gnodux"))).Returning(N("id"))
+	}
+	newUpdate := func() *UpdateExpr {
+		return Update(N("user")).Set(N("age").Eq(Const(18))).Where(Eq(N("id"), Const(1))).Returning(All)
+	}
+	newDelete := func() *DeleteExpr {
+		return Delete(N("user")).Where(Eq(N("id"), Const(1))).Returning(N("id"), N("name"))
+	}
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    Expr
+		want    string
+	}{
+		{
+			name:    "insert(mysql)",
+			dialect: dialect.MySQL,
+			expr:    newInsert(),
+			want:    "INSERT INTO `user` ( `name` ) VALUES ( :name )",
+		}, {
+			name:    "insert(postgres)",
+			dialect: dialect.Postgres,
+			expr:    newInsert(),
+			want:    `INSERT INTO "user" ( "name" ) VALUES ( :name ) RETURNING "id"`,
+		}, {
+			name:    "insert(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    newInsert(),
+			want:    "INSERT INTO [user] ( [name] ) OUTPUT INSERTED.[id] VALUES ( @name )",
+		}, {
+			name:    "upsert(postgres)",
+			dialect: dialect.Postgres,
+			expr:    Upsert(N("user"), N("name")).Values(N("name").Eq(Var("name", "gnodux"))).DoNothing().Returning(N("id")),
+			want:    `INSERT INTO "user" ( "name" ) VALUES ( :name ) ON CONFLICT ( "name" ) DO NOTHING RETURNING "id"`,
+		}, {
+			name:    "upsert(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    Upsert(N("user"), N("name")).Values(N("name").Eq(Var("name", "gnodux"))).Returning(N("id")),
+			want: "MERGE INTO [user] AS [target] USING (VALUES ( @name )) AS [source] ( [name] ) ON [target].[name] = [source].[name]" +
+				" WHEN NOT MATCHED THEN INSERT ( [name] ) VALUES ( [source].[name] ) OUTPUT INSERTED.[id];",
+		}, {
+			name:    "update(postgres)",
+			dialect: dialect.Postgres,
+			expr:    newUpdate(),
+			want:    `UPDATE "user" SET "age" = 18 WHERE "id" = 1 RETURNING *`,
+		}, {
+			name:    "update(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    newUpdate(),
+			want:    "UPDATE [user] SET [age] = 18 OUTPUT INSERTED.* WHERE [id] = 1",
+		}, {
+			name:    "delete(postgres)",
+			dialect: dialect.Postgres,
+			expr:    newDelete(),
+			want:    `DELETE FROM "user" WHERE "id" = 1 RETURNING "id","name"`,
+		}, {
+			name:    "delete(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    newDelete(),
+			want:    "DELETE FROM [user] OUTPUT DELETED.[id],DELETED.[name] WHERE [id] = 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(tt.dialect)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -15,12 +15,17 @@ var (
 )
 
 type SelectExpr struct {
+	WithExpr    *WithExpr
 	Columns     Expr
 	FromExpr    Expr
+	Joins       []*JoinExpr
 	WhereExpr   Expr
 	GroupByExpr Expr
 	HavingExpr  Expr
+	Windows     []*NamedWindowExpr
+	Compounds   []*CompoundExpr
 	OrderByExpr Expr
+	LockExpr    *LockExpr
 	limit       int
 	offset      int
 	withCount   bool
@@ -38,10 +43,23 @@ func (s *SelectExpr) WithoutCount() *SelectExpr {
 	return s
 }
 
+// BuildCountExpr 构建统计总数的查询，返回的查询不与原查询共享子表达式
 func (s *SelectExpr) BuildCountExpr() *SelectExpr {
+	c := s.Clone()
+	if len(c.Compounds) > 0 {
+		//复合查询需要先将结果作为派生表，再进行统计
+		with := c.WithExpr
+		c.WithExpr = nil
+		c.OrderByExpr = nil
+		c.LockExpr = nil
+		c.limit, c.offset, c.withCount = 0, 0, false
+		return Select(Count).With(with).From(c.As("t"))
+	}
 	return Select(Count).
-		From(s.FromExpr).
-		Where(s.WhereExpr).GroupBy(s.GroupByExpr).Having(s.HavingExpr)
+		With(c.WithExpr).
+		From(c.FromExpr).
+		Join(c.Joins...).
+		Where(c.WhereExpr).GroupBy(c.GroupByExpr).Having(c.HavingExpr)
 }
 func (s *SelectExpr) Limit(limit int) *SelectExpr {
 	s.limit = limit
@@ -51,14 +69,46 @@ func (s *SelectExpr) Offset(offset int) *SelectExpr {
 	s.offset = offset
 	return s
 }
+
+// Pagination 返回分页参数
+func (s *SelectExpr) Pagination() (limit, offset int) {
+	return s.limit, s.offset
+}
 func (s *SelectExpr) Select(columns ...Expr) *SelectExpr {
 	s.Columns = List(",", columns...)
 	return s
 }
+
+// With 设置WITH子句
+func (s *SelectExpr) With(w *WithExpr) *SelectExpr {
+	s.WithExpr = w
+	return s
+}
 func (s *SelectExpr) From(from Expr) *SelectExpr {
 	s.FromExpr = from
 	return s
 }
+
+// Join 添加连接表达式
+func (s *SelectExpr) Join(joins ...*JoinExpr) *SelectExpr {
+	s.Joins = append(s.Joins, joins...)
+	return s
+}
+func (s *SelectExpr) InnerJoin(table Expr, on Expr) *SelectExpr {
+	return s.Join(InnerJoin(table, on))
+}
+func (s *SelectExpr) LeftJoin(table Expr, on Expr) *SelectExpr {
+	return s.Join(LeftJoin(table, on))
+}
+func (s *SelectExpr) RightJoin(table Expr, on Expr) *SelectExpr {
+	return s.Join(RightJoin(table, on))
+}
+func (s *SelectExpr) FullJoin(table Expr, on Expr) *SelectExpr {
+	return s.Join(FullJoin(table, on))
+}
+func (s *SelectExpr) CrossJoin(table Expr) *SelectExpr {
+	return s.Join(CrossJoin(table))
+}
 func (s *SelectExpr) Where(exp Expr) *SelectExpr {
 	s.WhereExpr = exp
 	return s
@@ -77,6 +127,7 @@ func (s *SelectExpr) OrderBy(exps ...Expr) *SelectExpr {
 }
 
 func (s *SelectExpr) Format(buffer *TracedBuffer) {
+	formatWith(s.WithExpr, buffer)
 	buffer.AppendString(buffer.Keyword(keywords.Select))
 	buffer.AppendString(" ")
 	if s.Columns == nil {
@@ -86,6 +137,15 @@ func (s *SelectExpr) Format(buffer *TracedBuffer) {
 	}
 	buffer.AppendString(buffer.KeywordWithSpace(keywords.From))
 	s.FromExpr.Format(buffer)
+	if s.LockExpr != nil && buffer.LockHint {
+		s.LockExpr.formatHint(buffer)
+	}
+	left := s.FromExpr
+	for _, join := range s.Joins {
+		buffer.AppendString(keywords.Space)
+		join.format(buffer, left)
+		left = join.Table
+	}
 	if s.WhereExpr != nil {
 		buffer.AppendString(buffer.KeywordWithSpace(keywords.Where))
 		s.WhereExpr.Format(buffer)
@@ -98,20 +158,37 @@ func (s *SelectExpr) Format(buffer *TracedBuffer) {
 		buffer.AppendString(buffer.KeywordWithSpace(keywords.Having))
 		s.HavingExpr.Format(buffer)
 	}
+	if len(s.Windows) > 0 {
+		buffer.AppendKeywordWithSpace(keywords.Window)
+		for idx, window := range s.Windows {
+			if idx > 0 {
+				buffer.AppendString(keywords.Comma)
+			}
+			window.Format(buffer)
+		}
+	}
+	for _, compound := range s.Compounds {
+		buffer.AppendString(keywords.Space)
+		compound.Format(buffer)
+	}
 	if s.OrderByExpr != nil {
 		buffer.AppendString(buffer.KeywordWithSpace(keywords.OrderBy))
 		s.OrderByExpr.Format(buffer)
 	}
 	if s.limit != 0 {
-
-		buffer.AppendString(buffer.KeywordWithSpace(keywords.Limit))
+		if s.OrderByExpr == nil && buffer.DefaultOrderBy != "" {
+			buffer.AppendKeywordWithSpace(keywords.OrderBy).AppendString(buffer.DefaultOrderBy)
+		}
 		buffer.AppendString(keywords.Space)
-		Var("limit", s.limit).Format(buffer)
-		buffer.AppendString(keywords.Space)
-		buffer.AppendString(buffer.KeywordWithSpace(keywords.Offset))
-		buffer.AppendString(keywords.Space)
-		Var("offset", s.offset).Format(buffer)
+		buffer.AppendString(buffer.Pagination(func() string {
+			return buffer.Capture(Var(buffer.UniqueName("limit"), s.limit))
+		}, func() string {
+			return buffer.Capture(Var(buffer.UniqueName("offset"), s.offset))
+		}))
+	}
+	if s.LockExpr != nil && !buffer.LockHint {
 		buffer.AppendString(keywords.Space)
+		s.LockExpr.Format(buffer)
 	}
 }
 
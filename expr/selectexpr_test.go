@@ -87,3 +87,65 @@ func TestSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectPagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  *dialect.Dialect
+		named    bool
+		expr     Expr
+		want     string
+		wantArgs any
+	}{
+		{
+			name:     "mysql",
+			dialect:  dialect.MySQL,
+			named:    true,
+			expr:     Select(All).From(N("user")).OrderBy(N("id")).Limit(10).Offset(20),
+			want:     "SELECT * FROM `user` ORDER BY `id` LIMIT :limit OFFSET :offset",
+			wantArgs: map[string]any{"limit": 10, "offset": 20},
+		}, {
+			name:     "mysql(positional)",
+			dialect:  dialect.MySQL,
+			expr:     Select(All).From(N("user")).Where(Eq(N("role"), Var("role", "admin"))).Limit(10).Offset(20),
+			want:     "SELECT * FROM `user` WHERE `role` = ? LIMIT ? OFFSET ?",
+			wantArgs: []any{"admin", 10, 20},
+		}, {
+			name:     "name collision",
+			dialect:  dialect.MySQL,
+			named:    true,
+			expr:     Select(All).From(N("user")).Where(Eq(N("age"), Var("limit", 18))).Limit(18),
+			want:     "SELECT * FROM `user` WHERE `age` = :limit LIMIT :limit_1 OFFSET :offset",
+			wantArgs: map[string]any{"limit": 18, "limit_1": 18, "offset": 0},
+		}, {
+			name:     "sql server",
+			dialect:  dialect.SQLServer,
+			named:    true,
+			expr:     Select(All).From(N("user")).OrderBy(N("id")).Limit(10).Offset(20),
+			want:     "SELECT * FROM [user] ORDER BY [id] OFFSET @offset ROWS FETCH NEXT @limit ROWS ONLY",
+			wantArgs: map[string]any{"limit": 10, "offset": 20},
+		}, {
+			name:     "sql server(positional without order by)",
+			dialect:  dialect.SQLServer,
+			expr:     Select(All).From(N("user")).Limit(10).Offset(20),
+			want:     "SELECT * FROM [user] ORDER BY (SELECT NULL) OFFSET ? ROWS FETCH NEXT ? ROWS ONLY",
+			wantArgs: []any{20, 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(tt.dialect)
+			if tt.named {
+				sql, args, err := buf.BuildNamed(tt.expr)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, sql)
+				assert.Equal(t, tt.wantArgs, args)
+			} else {
+				sql, args, err := buf.Build(tt.expr)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, sql)
+				assert.Equal(t, tt.wantArgs, args)
+			}
+		})
+	}
+}
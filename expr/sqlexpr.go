@@ -9,6 +9,7 @@ package expr
 //go:generate go run genindx.go
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gnodux/sqlmx/dialect"
 	"reflect"
@@ -70,6 +71,8 @@ type TracedBuffer struct {
 	//args 位置参数
 	args     []any
 	NamedVar bool
+	//err 格式化过程中产生的错误(例如当前方言无法表达的语句)
+	err error
 	*dialect.Dialect
 	strings.Builder
 }
@@ -85,6 +88,49 @@ func (t *TracedBuffer) AppendNamedArg(name string, value any) *TracedBuffer {
 	t.namedArgs[name] = value
 	return t
 }
+
+// BindNamedArg 绑定命名参数并返回实际使用的参数名称
+// 如果参数名称已被使用且值不同(例如子查询与外层查询使用了相同的参数名称)，则自动生成新的参数名称，避免参数冲突
+func (t *TracedBuffer) BindNamedArg(name string, value any) string {
+	if t.namedArgs == nil {
+		t.namedArgs = map[string]any{}
+	}
+	bindName := name
+	for idx := 1; ; idx++ {
+		exists, ok := t.namedArgs[bindName]
+		if !ok {
+			break
+		}
+		if reflect.DeepEqual(exists, value) {
+			return bindName
+		}
+		bindName = fmt.Sprintf("%s_%d", name, idx)
+	}
+	t.namedArgs[bindName] = value
+	return bindName
+}
+
+// UniqueName 返回一个在当前buffer中未被使用的参数名称，用于内部生成的参数(例如分页参数)，避免与用户参数冲突
+func (t *TracedBuffer) UniqueName(name string) string {
+	uniqueName := name
+	for idx := 1; ; idx++ {
+		if _, ok := t.namedArgs[uniqueName]; !ok {
+			return uniqueName
+		}
+		uniqueName = fmt.Sprintf("%s_%d", name, idx)
+	}
+}
+
+// Capture 将表达式格式化为字符串而不写入buffer，表达式中的参数仍然按顺序记录在当前buffer中
+func (t *TracedBuffer) Capture(exp Expr) string {
+	saved := t.Builder.String()
+	t.Builder.Reset()
+	exp.Format(t)
+	captured := t.Builder.String()
+	t.Builder.Reset()
+	t.Builder.WriteString(saved)
+	return captured
+}
 func (t *TracedBuffer) AppendArg(value any) *TracedBuffer {
 	t.args = append(t.args, value)
 	return t
@@ -132,17 +178,31 @@ func (t *TracedBuffer) AppendKeywordWithSpace(keyword string) *TracedBuffer {
 	t.Builder.WriteString(t.KeywordWithSpace(keyword))
 	return t
 }
+
+// AddError 记录格式化过程中的错误，Format无法返回错误，由Build/BuildNamed统一返回
+func (t *TracedBuffer) AddError(err error) {
+	t.err = errors.Join(t.err, err)
+}
+
+// Err 返回格式化过程中记录的错误
+func (t *TracedBuffer) Err() error {
+	return t.err
+}
 func (t *TracedBuffer) Build(exp Expr) (string, []any, error) {
 	t.NamedVar = false
 	t.Builder.Reset()
+	t.args = nil
+	t.err = nil
 	exp.Format(t)
-	return t.Builder.String(), t.args, nil
+	return t.Builder.String(), t.args, t.err
 }
 func (t *TracedBuffer) BuildNamed(exp Expr) (string, map[string]any, error) {
 	t.NamedVar = true
 	t.Builder.Reset()
+	t.namedArgs = nil
+	t.err = nil
 	exp.Format(t)
-	return t.Builder.String(), t.namedArgs, nil
+	return t.Builder.String(), t.namedArgs, t.err
 }
 
 type Expr interface {
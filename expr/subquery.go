@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import "github.com/gnodux/sqlmx/expr/keywords"
+
+// SubQueryExpr 子查询表达式，格式化时自动添加括号
+// 子查询中的命名参数与外层查询共用同一个TracedBuffer，参数名称冲突时会自动重命名
+type SubQueryExpr struct {
+	Query Expr
+}
+
+func (s *SubQueryExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendString("(")
+	s.Query.Format(buffer)
+	buffer.AppendString(")")
+}
+
+// SubQuery 子查询，例如：SubQuery(Select(N("id")).From(N("user")))会被格式化为：(SELECT `id` FROM `user`)
+func SubQuery(query Expr) *SubQueryExpr {
+	if sq, ok := query.(*SubQueryExpr); ok {
+		return sq
+	}
+	return &SubQueryExpr{Query: query}
+}
+
+// As 将查询作为派生表或者查询列使用，例如：(SELECT ...) AS `t`
+func (s *SelectExpr) As(alias string) *AliasExpr {
+	return Alias(SubQuery(s), alias)
+}
+
+// Exists EXISTS (SELECT ...)
+func Exists(query Expr) *UnaryExpr {
+	return Unary(keywords.Exists, SubQuery(query))
+}
+
+// NotExists NOT EXISTS (SELECT ...)
+func NotExists(query Expr) *UnaryExpr {
+	return Unary(keywords.NotExists, SubQuery(query))
+}
+
+// InQuery left IN (SELECT ...)
+func InQuery(left Expr, query Expr) *BinaryExpr {
+	return &BinaryExpr{Left: left, Space: " ", Operator: keywords.In, Right: SubQuery(query)}
+}
+
+// NotInQuery left NOT IN (SELECT ...)
+func NotInQuery(left Expr, query Expr) *BinaryExpr {
+	return &BinaryExpr{Left: left, Space: " ", Operator: keywords.NotIn, Right: SubQuery(query)}
+}
+
+// singleQuery 判断values是否仅包含一个子查询
+func singleQuery(values []any) (Expr, bool) {
+	if len(values) != 1 {
+		return nil, false
+	}
+	switch q := values[0].(type) {
+	case *SelectExpr:
+		return q, true
+	case *SubQueryExpr:
+		return q, true
+	}
+	return nil, false
+}
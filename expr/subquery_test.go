@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSubQuery(t *testing.T) {
+	admins := Select(N("name")).From(N("role")).Where(Eq(N("name"), Var("name", "admin")))
+	tests := []struct {
+		name     string
+		expr     Expr
+		want     string
+		wantArgs map[string]any
+	}{
+		{
+			name:     "exists",
+			expr:     Select(All).From(Alias(N("user"), "u")).Where(Exists(Select(Raw(1)).From(Alias(N("role"), "r")).Where(Eq(N("name", "r"), N("role", "u"))))),
+			want:     "SELECT * FROM `user` AS `u` WHERE EXISTS (SELECT 1 FROM `role` AS `r` WHERE `r`.`name` = `u`.`role`)",
+			wantArgs: nil,
+		}, {
+			name: "not exists",
+			expr: Select(All).From(N("user")).Where(NotExists(Select(Raw(1)).From(N("role")))),
+			want: "SELECT * FROM `user` WHERE NOT EXISTS (SELECT 1 FROM `role`)",
+		}, {
+			name:     "in",
+			expr:     Select(All).From(N("user")).Where(N("role").In(admins)),
+			want:     "SELECT * FROM `user` WHERE `role` IN (SELECT `name` FROM `role` WHERE `name` = :name)",
+			wantArgs: map[string]any{"name": "admin"},
+		}, {
+			name:     "not in",
+			expr:     Select(All).From(N("user")).Where(N("role").NotIn(admins)),
+			want:     "SELECT * FROM `user` WHERE `role` NOT IN (SELECT `name` FROM `role` WHERE `name` = :name)",
+			wantArgs: map[string]any{"name": "admin"},
+		}, {
+			name:     "scalar",
+			expr:     Select(All).From(N("account_book")).Where(Gt(N("balance"), Select(Fn("AVG", N("balance"))).From(N("account_book")))),
+			want:     "SELECT * FROM `account_book` WHERE `balance` > (SELECT AVG(`balance`) FROM `account_book`)",
+			wantArgs: nil,
+		}, {
+			name:     "derived table",
+			expr:     Select(All).From(Select(N("id")).From(N("user")).As("t")),
+			want:     "SELECT * FROM (SELECT `id` FROM `user`) AS `t`",
+			wantArgs: nil,
+		}, {
+			name: "select list",
+			expr: Select(N("id"), Select(CountAll).From(N("role")).Where(Eq(N("name"), N("role", "user"))).As("roles")).From(N("user")),
+			want: "SELECT `id`,(SELECT COUNT(*) FROM `role` WHERE `name` = `user`.`role`) AS `roles` FROM `user`",
+		}, {
+			name: "name collision",
+			expr: Select(All).From(N("user")).Where(And(
+				Eq(N("name"), Var("name", "gnodux")),
+				N("role").In(admins),
+			)),
+			want:     "SELECT * FROM `user` WHERE `name` = :name AND `role` IN (SELECT `name` FROM `role` WHERE `name` = :name_1)",
+			wantArgs: map[string]any{"name": "gnodux", "name_1": "admin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := NewTracedBuffer(dialect.MySQL).BuildNamed(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sql)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
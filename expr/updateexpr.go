@@ -8,9 +8,12 @@ package expr
 import "github.com/gnodux/sqlmx/expr/keywords"
 
 type UpdateExpr struct {
+	WithExpr  *WithExpr
 	Table     Expr
 	Values    []Expr
 	WhereExpr Expr
+	//ReturningExprs 更新后返回的列
+	ReturningExprs []Expr
 }
 
 func (u *UpdateExpr) Update(table Expr) *UpdateExpr {
@@ -21,6 +24,12 @@ func (u *UpdateExpr) Set(values ...Expr) *UpdateExpr {
 	u.Values = values
 	return u
 }
+
+// With 设置WITH子句
+func (u *UpdateExpr) With(w *WithExpr) *UpdateExpr {
+	u.WithExpr = w
+	return u
+}
 func (u *UpdateExpr) Where(exp Expr) *UpdateExpr {
 	u.WhereExpr = exp
 	return u
@@ -31,6 +40,7 @@ func Update(table Expr) *UpdateExpr {
 }
 
 func (u *UpdateExpr) Format(buf *TracedBuffer) {
+	formatWith(u.WithExpr, buf)
 	buf.AppendKeyword(keywords.Update).AppendString(keywords.Space)
 	u.Table.Format(buf)
 	buf.AppendKeywordWithSpace(keywords.Set)
@@ -40,8 +50,10 @@ func (u *UpdateExpr) Format(buf *TracedBuffer) {
 		}
 		v.Format(buf)
 	}
+	formatOutput(buf, keywords.Inserted, u.ReturningExprs)
 	if u.WhereExpr != nil {
 		buf.AppendKeywordWithSpace(keywords.Where)
 		u.WhereExpr.Format(buf)
 	}
+	formatReturning(buf, u.ReturningExprs)
 }
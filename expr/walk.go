@@ -0,0 +1,334 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+// RewriteFn 改写函数，返回替换后的表达式
+type RewriteFn func(Expr) Expr
+
+// Walk 深度优先遍历表达式树，visit返回false时不再访问当前节点的子节点
+//
+// 包外的表达式(例如meta.Entity、meta.Column)作为叶子节点访问
+func Walk(exp Expr, visit func(Expr) bool) {
+	if exp == nil || !visit(exp) {
+		return
+	}
+	eachChild(exp, func(child Expr) {
+		Walk(child, visit)
+	})
+}
+
+// Rewrite 自底向上改写表达式树：先改写子节点，再使用fn改写当前节点，返回改写后的根节点
+//
+// 子节点直接在原表达式上替换；fn返回nil表示删除该节点，仅对Expr类型的字段和列表有效，
+// 类型固定的字段(例如SelectExpr.Joins、InsertExpr.ValueExprs)只接受相同类型的结果，否则保持不变
+func Rewrite(exp Expr, fn RewriteFn) Expr {
+	if exp == nil {
+		return nil
+	}
+	rewriteChildren(exp, func(child Expr) Expr {
+		return Rewrite(child, fn)
+	})
+	return fn(exp)
+}
+
+// Inspect 遍历表达式树，返回所有类型为T的节点，例如：Inspect[*NameExpr](queryExpr)
+func Inspect[T Expr](exp Expr) []T {
+	var nodes []T
+	Walk(exp, func(e Expr) bool {
+		if n, ok := e.(T); ok {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	return nodes
+}
+
+// rewriteChildren 使用fn替换exp的所有直接子节点
+func rewriteChildren(exp Expr, fn RewriteFn) {
+	switch e := exp.(type) {
+	case *AroundExpr:
+		e.Prefix = rewriteExpr(e.Prefix, fn)
+		e.Expr = rewriteExpr(e.Expr, fn)
+		e.Suffix = rewriteExpr(e.Suffix, fn)
+	case *AliasExpr:
+		e.Expr = rewriteExpr(e.Expr, fn)
+	case *BinaryExpr:
+		e.Left = rewriteExpr(e.Left, fn)
+		e.Right = rewriteExpr(e.Right, fn)
+	case *UnaryExpr:
+		e.Expr = rewriteExpr(e.Expr, fn)
+	case *ListExpr:
+		e.Prefix = rewriteExpr(e.Prefix, fn)
+		e.ExprList = rewriteExprs(e.ExprList, fn)
+		e.Suffix = rewriteExpr(e.Suffix, fn)
+	case *FuncExpr:
+		e.Args = rewriteExprs(e.Args, fn)
+	case *BetweenExpr:
+		e.Left = rewriteExpr(e.Left, fn)
+		e.Start = rewriteExpr(e.Start, fn)
+		e.End = rewriteExpr(e.End, fn)
+	case *SubQueryExpr:
+		e.Query = rewriteExpr(e.Query, fn)
+	case *SelectExpr:
+		e.WithExpr = rewriteNode(e.WithExpr, fn)
+		e.Columns = rewriteExpr(e.Columns, fn)
+		e.FromExpr = rewriteExpr(e.FromExpr, fn)
+		for idx := range e.Joins {
+			e.Joins[idx] = rewriteNode(e.Joins[idx], fn)
+		}
+		e.WhereExpr = rewriteExpr(e.WhereExpr, fn)
+		e.GroupByExpr = rewriteExpr(e.GroupByExpr, fn)
+		e.HavingExpr = rewriteExpr(e.HavingExpr, fn)
+		for idx := range e.Windows {
+			e.Windows[idx] = rewriteNode(e.Windows[idx], fn)
+		}
+		for idx := range e.Compounds {
+			e.Compounds[idx] = rewriteNode(e.Compounds[idx], fn)
+		}
+		e.OrderByExpr = rewriteExpr(e.OrderByExpr, fn)
+		e.LockExpr = rewriteNode(e.LockExpr, fn)
+	case *JoinExpr:
+		e.Table = rewriteExpr(e.Table, fn)
+		e.OnExpr = rewriteExpr(e.OnExpr, fn)
+		e.UsingExprs = rewriteExprs(e.UsingExprs, fn)
+	case *WithExpr:
+		for idx := range e.CTEs {
+			e.CTEs[idx] = rewriteNode(e.CTEs[idx], fn)
+		}
+	case *CTE:
+		e.Query = rewriteExpr(e.Query, fn)
+	case *CompoundExpr:
+		e.Query = rewriteExpr(e.Query, fn)
+	case *WindowExpr:
+		e.PartitionByExprs = rewriteExprs(e.PartitionByExprs, fn)
+		e.OrderByExpr = rewriteExpr(e.OrderByExpr, fn)
+		e.FrameExpr = rewriteNode(e.FrameExpr, fn)
+	case *OverExpr:
+		e.Func = rewriteExpr(e.Func, fn)
+		e.Window = rewriteNode(e.Window, fn)
+	case *NamedWindowExpr:
+		e.Window = rewriteNode(e.Window, fn)
+	case *InsertExpr:
+		e.Table = rewriteExpr(e.Table, fn)
+		for idx := range e.ValueExprs {
+			e.ValueExprs[idx] = rewriteNode(e.ValueExprs[idx], fn)
+		}
+		e.ColumnExprs = rewriteExprs(e.ColumnExprs, fn)
+		if e.RowExprs != nil {
+			//行可能与Split生成的语句共享，使用新的切片保存结果
+			rows := make([][]Expr, len(e.RowExprs))
+			for idx, row := range e.RowExprs {
+				rows[idx] = rewriteExprs(row, fn)
+			}
+			e.RowExprs = rows
+		}
+		e.QueryExpr = rewriteExpr(e.QueryExpr, fn)
+		e.ConflictColumns = rewriteExprs(e.ConflictColumns, fn)
+		for idx := range e.UpdateExprs {
+			e.UpdateExprs[idx] = rewriteNode(e.UpdateExprs[idx], fn)
+		}
+		e.ReturningExprs = rewriteExprs(e.ReturningExprs, fn)
+	case *ExcludedExpr:
+		e.Column = rewriteExpr(e.Column, fn)
+	case *UpdateExpr:
+		e.WithExpr = rewriteNode(e.WithExpr, fn)
+		e.Table = rewriteExpr(e.Table, fn)
+		e.Values = rewriteExprs(e.Values, fn)
+		e.WhereExpr = rewriteExpr(e.WhereExpr, fn)
+		e.ReturningExprs = rewriteExprs(e.ReturningExprs, fn)
+	case *DeleteExpr:
+		e.WithExpr = rewriteNode(e.WithExpr, fn)
+		e.Table = rewriteExpr(e.Table, fn)
+		e.WhereExpr = rewriteExpr(e.WhereExpr, fn)
+		e.ReturningExprs = rewriteExprs(e.ReturningExprs, fn)
+	case *CaseExpr:
+		e.Value = rewriteExpr(e.Value, fn)
+		for idx := range e.Whens {
+			e.Whens[idx] = rewriteNode(e.Whens[idx], fn)
+		}
+		e.ElseExpr = rewriteExpr(e.ElseExpr, fn)
+	case *WhenExpr:
+		e.Cond = rewriteExpr(e.Cond, fn)
+		e.Result = rewriteExpr(e.Result, fn)
+	case *CastExpr:
+		e.Expr = rewriteExpr(e.Expr, fn)
+	case *DistinctExpr:
+		e.Left = rewriteExpr(e.Left, fn)
+		e.Right = rewriteExpr(e.Right, fn)
+	}
+}
+
+// eachChild 按顺序访问exp的所有直接子节点，只读取不修改，可以在多个goroutine中同时遍历同一个表达式
+func eachChild(exp Expr, fn func(Expr)) {
+	switch e := exp.(type) {
+	case *AroundExpr:
+		visitExpr(e.Prefix, fn)
+		visitExpr(e.Expr, fn)
+		visitExpr(e.Suffix, fn)
+	case *AliasExpr:
+		visitExpr(e.Expr, fn)
+	case *BinaryExpr:
+		visitExpr(e.Left, fn)
+		visitExpr(e.Right, fn)
+	case *UnaryExpr:
+		visitExpr(e.Expr, fn)
+	case *ListExpr:
+		visitExpr(e.Prefix, fn)
+		visitExprs(e.ExprList, fn)
+		visitExpr(e.Suffix, fn)
+	case *FuncExpr:
+		visitExprs(e.Args, fn)
+	case *BetweenExpr:
+		visitExpr(e.Left, fn)
+		visitExpr(e.Start, fn)
+		visitExpr(e.End, fn)
+	case *SubQueryExpr:
+		visitExpr(e.Query, fn)
+	case *SelectExpr:
+		visitNode(e.WithExpr, fn)
+		visitExpr(e.Columns, fn)
+		visitExpr(e.FromExpr, fn)
+		for _, child := range e.Joins {
+			visitNode(child, fn)
+		}
+		visitExpr(e.WhereExpr, fn)
+		visitExpr(e.GroupByExpr, fn)
+		visitExpr(e.HavingExpr, fn)
+		for _, child := range e.Windows {
+			visitNode(child, fn)
+		}
+		for _, child := range e.Compounds {
+			visitNode(child, fn)
+		}
+		visitExpr(e.OrderByExpr, fn)
+		visitNode(e.LockExpr, fn)
+	case *JoinExpr:
+		visitExpr(e.Table, fn)
+		visitExpr(e.OnExpr, fn)
+		visitExprs(e.UsingExprs, fn)
+	case *WithExpr:
+		for _, child := range e.CTEs {
+			visitNode(child, fn)
+		}
+	case *CTE:
+		visitExpr(e.Query, fn)
+	case *CompoundExpr:
+		visitExpr(e.Query, fn)
+	case *WindowExpr:
+		visitExprs(e.PartitionByExprs, fn)
+		visitExpr(e.OrderByExpr, fn)
+		visitNode(e.FrameExpr, fn)
+	case *OverExpr:
+		visitExpr(e.Func, fn)
+		visitNode(e.Window, fn)
+	case *NamedWindowExpr:
+		visitNode(e.Window, fn)
+	case *InsertExpr:
+		visitExpr(e.Table, fn)
+		for _, child := range e.ValueExprs {
+			visitNode(child, fn)
+		}
+		visitExprs(e.ColumnExprs, fn)
+		for _, child := range e.RowExprs {
+			visitExprs(child, fn)
+		}
+		visitExpr(e.QueryExpr, fn)
+		visitExprs(e.ConflictColumns, fn)
+		for _, child := range e.UpdateExprs {
+			visitNode(child, fn)
+		}
+		visitExprs(e.ReturningExprs, fn)
+	case *ExcludedExpr:
+		visitExpr(e.Column, fn)
+	case *UpdateExpr:
+		visitNode(e.WithExpr, fn)
+		visitExpr(e.Table, fn)
+		visitExprs(e.Values, fn)
+		visitExpr(e.WhereExpr, fn)
+		visitExprs(e.ReturningExprs, fn)
+	case *DeleteExpr:
+		visitNode(e.WithExpr, fn)
+		visitExpr(e.Table, fn)
+		visitExpr(e.WhereExpr, fn)
+		visitExprs(e.ReturningExprs, fn)
+	case *CaseExpr:
+		visitExpr(e.Value, fn)
+		for _, child := range e.Whens {
+			visitNode(child, fn)
+		}
+		visitExpr(e.ElseExpr, fn)
+	case *WhenExpr:
+		visitExpr(e.Cond, fn)
+		visitExpr(e.Result, fn)
+	case *CastExpr:
+		visitExpr(e.Expr, fn)
+	case *DistinctExpr:
+		visitExpr(e.Left, fn)
+		visitExpr(e.Right, fn)
+	}
+}
+
+func rewriteExpr(exp Expr, fn RewriteFn) Expr {
+	if exp == nil {
+		return nil
+	}
+	return fn(exp)
+}
+
+// rewriteExprs 替换列表中的每个表达式，并移除结果为nil的表达式
+//
+// 结果保存在新的切片中，不会修改调用者传入的切片(例如List(",", cols...)中的cols)
+func rewriteExprs(exps []Expr, fn RewriteFn) []Expr {
+	if exps == nil {
+		return nil
+	}
+	result := make([]Expr, 0, len(exps))
+	for _, exp := range exps {
+		if exp == nil {
+			continue
+		}
+		if r := fn(exp); r != nil {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// rewriteNode 替换类型固定的子节点，结果类型不一致时保持原节点
+func rewriteNode[T any, P interface {
+	*T
+	Expr
+}](node P, fn RewriteFn) P {
+	if node == nil {
+		return nil
+	}
+	if r, ok := fn(node).(P); ok && r != nil {
+		return r
+	}
+	return node
+}
+
+func visitExpr(exp Expr, fn func(Expr)) {
+	if exp != nil {
+		fn(exp)
+	}
+}
+
+func visitExprs(exps []Expr, fn func(Expr)) {
+	for _, exp := range exps {
+		visitExpr(exp, fn)
+	}
+}
+
+// visitNode 访问类型固定的子节点，忽略nil指针
+func visitNode[T any, P interface {
+	*T
+	Expr
+}](node P, fn func(Expr)) {
+	if node != nil {
+		fn(node)
+	}
+}
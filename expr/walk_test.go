@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	query := Select(N("id"), Case().When(N("age").Gt(Const(18)), Const(1)).Else(Const(0))).
+		From(N("user")).
+		LeftJoin(N("role"), Eq(N("role_id"), N("id", "role"))).
+		Where(And(N("name").Eq(Const("a")), InQuery(N("dept"), Select(N("id")).From(N("dept")))))
+	var names []string
+	for _, n := range Inspect[*NameExpr](query) {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"id", "age", "user", "role", "role_id", "id", "name", "dept", "id", "dept"}, names)
+
+	//不访问子查询
+	var count int
+	Walk(query.WhereExpr, func(e Expr) bool {
+		if _, ok := e.(*SubQueryExpr); ok {
+			return false
+		}
+		if _, ok := e.(*NameExpr); ok {
+			count++
+		}
+		return true
+	})
+	assert.Equal(t, 2, count)
+}
+
+func TestRewrite(t *testing.T) {
+	//租户条件注入：所有查询(包括子查询)增加tenant_id条件
+	tenant := func(e Expr) Expr {
+		if s, ok := e.(*SelectExpr); ok {
+			cond := Expr(Eq(N("tenant_id"), Const(1)))
+			if s.WhereExpr != nil {
+				cond = And(s.WhereExpr, cond)
+			}
+			s.Where(cond)
+		}
+		return e
+	}
+	//列名替换
+	rename := func(e Expr) Expr {
+		if n, ok := e.(*NameExpr); ok && n.Name == "nick" {
+			return Name("nick_name", n.Qualifier...)
+		}
+		return e
+	}
+	tests := []struct {
+		name string
+		expr Expr
+		fn   RewriteFn
+		want string
+	}{
+		{
+			name: "tenant",
+			expr: Select(All).From(N("user")).Where(InQuery(N("role_id"), Select(N("id")).From(N("role")))),
+			fn:   tenant,
+			want: "SELECT * FROM `user` WHERE `role_id` IN (SELECT `id` FROM `role` WHERE `tenant_id` = 1) AND `tenant_id` = 1",
+		}, {
+			name: "rename(update)",
+			expr: Update(N("user")).Set(N("nick").Eq(Const("a"))).Where(Eq(N("nick", "user"), Const("b"))),
+			fn:   rename,
+			want: "UPDATE `user` SET `nick_name` = 'a' WHERE `user`.`nick_name` = 'b'",
+		}, {
+			name: "rename(insert)",
+			expr: InsertInto(N("user"), N("nick").Eq(Const("a"))).Returning(N("nick")),
+			fn:   rename,
+			want: "INSERT INTO `user` ( `nick_name` ) VALUES ( 'a' )",
+		}, {
+			name: "remove",
+			expr: Delete(N("user")).Where(And(N("id").Eq(Const(1)), N("is_deleted").Eq(Const(false)))),
+			fn: func(e Expr) Expr {
+				if b, ok := e.(*BinaryExpr); ok && exprName(b.Left) == "is_deleted" {
+					return nil
+				}
+				return e
+			},
+			want: "DELETE FROM `user` WHERE `id` = 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UseRewrite(tt.fn)(tt.expr)
+			buf := NewTracedBuffer(dialect.MySQL)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWalkConcurrent(t *testing.T) {
+	//Walk只读取表达式，多个goroutine可以同时遍历同一个表达式(go test -race)
+	query := Select(N("id"), N("name")).From(N("user")).
+		LeftJoin(N("role"), Eq(N("role_id"), N("id", "role"))).
+		Where(And(N("name").Eq(Const("a")), In(N("id"), "id", 1, 2)))
+	want := len(Inspect[*NameExpr](query))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, want, len(Inspect[*NameExpr](query)))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRewriteKeepsCallerSlice(t *testing.T) {
+	cols := []Expr{N("a"), N("b"), N("c")}
+	list := List(",", cols...)
+	Rewrite(list, func(e Expr) Expr {
+		if n, ok := e.(*NameExpr); ok && n.Name == "a" {
+			return nil
+		}
+		return e
+	})
+	assert.Equal(t, []Expr{N("a"), N("b"), N("c")}, cols)
+	assert.Len(t, list.ExprList, 2)
+}
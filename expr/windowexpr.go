@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"fmt"
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// FrameExpr 窗口帧，例如：ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+type FrameExpr struct {
+	//Unit 帧单位：keywords.Rows或keywords.Range
+	Unit string
+	//Start 开始边界，例如：keywords.UnboundedPreceding、Preceding(1)
+	Start string
+	//End 结束边界，为空时仅使用开始边界
+	End string
+}
+
+func (f *FrameExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendKeyword(f.Unit).AppendString(keywords.Space)
+	if f.End == "" {
+		buffer.AppendKeyword(f.Start)
+		return
+	}
+	buffer.AppendKeyword(keywords.Between).AppendString(keywords.Space).
+		AppendKeyword(f.Start).
+		AppendKeywordWithSpace(keywords.And).
+		AppendKeyword(f.End)
+}
+
+// Preceding 向前n行的帧边界，例如：Preceding(3) => 3 PRECEDING
+func Preceding(n int) string {
+	return fmt.Sprintf("%d %s", n, keywords.Preceding)
+}
+
+// Following 向后n行的帧边界，例如：Following(3) => 3 FOLLOWING
+func Following(n int) string {
+	return fmt.Sprintf("%d %s", n, keywords.Following)
+}
+
+// WindowExpr 窗口定义，例如：(PARTITION BY `dept` ORDER BY `salary` DESC)
+type WindowExpr struct {
+	//Base 引用的命名窗口
+	Base             string
+	PartitionByExprs []Expr
+	OrderByExpr      Expr
+	FrameExpr        *FrameExpr
+}
+
+// PartitionBy 设置分区
+func (w *WindowExpr) PartitionBy(exps ...Expr) *WindowExpr {
+	w.PartitionByExprs = exps
+	return w
+}
+
+// OrderBy 设置窗口内排序
+func (w *WindowExpr) OrderBy(exps ...Expr) *WindowExpr {
+	w.OrderByExpr = List(keywords.Comma, exps...)
+	return w
+}
+
+// Rows 设置ROWS帧，end为空时仅使用开始边界
+func (w *WindowExpr) Rows(start, end string) *WindowExpr {
+	w.FrameExpr = &FrameExpr{Unit: keywords.Rows, Start: start, End: end}
+	return w
+}
+
+// Range 设置RANGE帧，end为空时仅使用开始边界
+func (w *WindowExpr) Range(start, end string) *WindowExpr {
+	w.FrameExpr = &FrameExpr{Unit: keywords.Range, Start: start, End: end}
+	return w
+}
+
+func (w *WindowExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendString("(")
+	space := ""
+	if w.Base != "" {
+		buffer.AppendString(buffer.SQLNameFunc(w.Base))
+		space = keywords.Space
+	}
+	if len(w.PartitionByExprs) > 0 {
+		buffer.AppendString(space).AppendKeyword(keywords.PartitionBy).AppendString(keywords.Space)
+		List(keywords.Comma, w.PartitionByExprs...).Format(buffer)
+		space = keywords.Space
+	}
+	if w.OrderByExpr != nil {
+		buffer.AppendString(space).AppendKeyword(keywords.OrderBy).AppendString(keywords.Space)
+		w.OrderByExpr.Format(buffer)
+		space = keywords.Space
+	}
+	if w.FrameExpr != nil {
+		buffer.AppendString(space)
+		w.FrameExpr.Format(buffer)
+	}
+	buffer.AppendString(")")
+}
+
+// OverExpr 窗口函数，例如：ROW_NUMBER() OVER (PARTITION BY `dept` ORDER BY `salary` DESC)
+// 可以在查询列和ORDER BY中使用
+type OverExpr struct {
+	Func Expr
+	//Window 窗口定义，与WindowName二选一
+	Window *WindowExpr
+	//WindowName 引用SelectExpr中定义的命名窗口
+	WindowName string
+}
+
+func (o *OverExpr) Format(buffer *TracedBuffer) {
+	o.Func.Format(buffer)
+	buffer.AppendKeywordWithSpace(keywords.Over)
+	if o.Window != nil {
+		o.Window.Format(buffer)
+	} else if o.WindowName != "" {
+		buffer.AppendString(buffer.SQLNameFunc(o.WindowName))
+	} else {
+		buffer.AppendString("()")
+	}
+}
+
+// NamedWindowExpr 命名窗口，例如：`w` AS (PARTITION BY `dept`)
+type NamedWindowExpr struct {
+	Name   string
+	Window *WindowExpr
+}
+
+func (n *NamedWindowExpr) Format(buffer *TracedBuffer) {
+	buffer.AppendString(buffer.SQLNameFunc(n.Name))
+	buffer.AppendKeywordWithSpace(keywords.AS)
+	n.Window.Format(buffer)
+}
+
+// Window 定义命名窗口(WINDOW子句)
+func (s *SelectExpr) Window(name string, window *WindowExpr) *SelectExpr {
+	s.Windows = append(s.Windows, &NamedWindowExpr{Name: name, Window: window})
+	return s
+}
+
+// Over 使用窗口定义
+func (f *FuncExpr) Over(window *WindowExpr) *OverExpr {
+	return Over(f, window)
+}
+
+// OverWindow 使用命名窗口
+func (f *FuncExpr) OverWindow(name string) *OverExpr {
+	return OverWindow(f, name)
+}
+
+// Window 创建一个窗口定义，base为引用的命名窗口(可以为空)
+func Window(base string) *WindowExpr {
+	return &WindowExpr{Base: base}
+}
+
+// Over 创建窗口函数，window为nil时格式化为OVER ()
+func Over(fn Expr, window *WindowExpr) *OverExpr {
+	return &OverExpr{Func: fn, Window: window}
+}
+
+// OverWindow 创建使用命名窗口的窗口函数
+func OverWindow(fn Expr, name string) *OverExpr {
+	return &OverExpr{Func: fn, WindowName: name}
+}
+
+func RowNumber() *FuncExpr {
+	return Fn("ROW_NUMBER")
+}
+func Rank() *FuncExpr {
+	return Fn("RANK")
+}
+func DenseRank() *FuncExpr {
+	return Fn("DENSE_RANK")
+}
+
+// Lag 取前offset行的值，defaultValue为可选的默认值
+func Lag(exp Expr, offset int, defaultValue ...Expr) *FuncExpr {
+	return Fn("LAG", append([]Expr{exp, Raw(offset)}, defaultValue...)...)
+}
+
+// Lead 取后offset行的值，defaultValue为可选的默认值
+func Lead(exp Expr, offset int, defaultValue ...Expr) *FuncExpr {
+	return Fn("LEAD", append([]Expr{exp, Raw(offset)}, defaultValue...)...)
+}
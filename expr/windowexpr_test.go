@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr/keywords"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name string
+		expr Expr
+		want string
+	}{
+		{
+			name: "row number",
+			expr: Select(N("name"), Alias(RowNumber().Over(Window("").PartitionBy(N("dept")).OrderBy(Desc(N("salary")))), "rn")).From(N("employee")),
+			want: "SELECT `name`,ROW_NUMBER() OVER (PARTITION BY `dept` ORDER BY `salary` DESC) AS `rn` FROM `employee`",
+		}, {
+			name: "empty window",
+			expr: Select(Alias(Rank().Over(nil), "r")).From(N("employee")),
+			want: "SELECT RANK() OVER () AS `r` FROM `employee`",
+		}, {
+			name: "lag and lead",
+			expr: Select(Lag(N("amount"), 1, Const(0)).Over(Window("").OrderBy(N("id"))), Lead(N("amount"), 2).Over(Window("").OrderBy(N("id")))).From(N("transaction")),
+			want: "SELECT LAG(`amount`,1,0) OVER (ORDER BY `id`),LEAD(`amount`,2) OVER (ORDER BY `id`) FROM `transaction`",
+		}, {
+			name: "running sum",
+			expr: Select(Alias(Fn("SUM", N("amount")).Over(Window("").PartitionBy(N("account_book_id")).OrderBy(N("create_time")).
+				Rows(keywords.UnboundedPreceding, keywords.CurrentRow)), "balance")).From(N("transaction")),
+			want: "SELECT SUM(`amount`) OVER (PARTITION BY `account_book_id` ORDER BY `create_time` ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS `balance` FROM `transaction`",
+		}, {
+			name: "frame start only",
+			expr: Select(Fn("AVG", N("amount")).Over(Window("").OrderBy(N("id")).Range(Preceding(3), ""))).From(N("transaction")),
+			want: "SELECT AVG(`amount`) OVER (ORDER BY `id` RANGE 3 PRECEDING) FROM `transaction`",
+		}, {
+			name: "named window",
+			expr: Select(RowNumber().OverWindow("w"), Alias(Fn("SUM", N("amount")).Over(Window("w").Rows(Preceding(1), Following(1))), "s")).
+				From(N("transaction")).
+				Window("w", Window("").PartitionBy(N("type")).OrderBy(N("id"))).
+				OrderBy(Desc(RowNumber().OverWindow("w"))),
+			want: "SELECT ROW_NUMBER() OVER `w`,SUM(`amount`) OVER (`w` ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) AS `s` FROM `transaction` WINDOW `w` AS (PARTITION BY `type` ORDER BY `id`) ORDER BY ROW_NUMBER() OVER `w` DESC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(dialect.MySQL)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import "github.com/gnodux/sqlmx/expr/keywords"
+
+// CTE 公共表表达式，例如：`org`(`id`,`parent_id`) AS (SELECT ...)
+type CTE struct {
+	Name    string
+	Columns []string
+	Query   Expr
+}
+
+func (c *CTE) Format(buffer *TracedBuffer) {
+	buffer.AppendString(buffer.SQLNameFunc(c.Name))
+	if len(c.Columns) > 0 {
+		buffer.AppendString("(")
+		for idx, col := range c.Columns {
+			if idx > 0 {
+				buffer.AppendString(keywords.Comma)
+			}
+			buffer.AppendString(buffer.SQLNameFunc(col))
+		}
+		buffer.AppendString(")")
+	}
+	buffer.AppendKeywordWithSpace(keywords.AS)
+	SubQuery(c.Query).Format(buffer)
+}
+
+// WithExpr WITH子句，可以附加到SelectExpr、UpdateExpr和DeleteExpr
+// 递归CTE在SQLServer下会被格式化为WITH(由方言的关键字映射处理)
+type WithExpr struct {
+	Recursive bool
+	CTEs      []*CTE
+}
+
+// As 添加一个命名的公共表表达式
+func (w *WithExpr) As(name string, query Expr, columns ...string) *WithExpr {
+	w.CTEs = append(w.CTEs, &CTE{Name: name, Query: query, Columns: columns})
+	return w
+}
+
+// Select 创建一个使用当前WITH子句的查询
+func (w *WithExpr) Select(columns ...Expr) *SelectExpr {
+	return Select(columns...).With(w)
+}
+
+// Update 创建一个使用当前WITH子句的更新
+func (w *WithExpr) Update(table Expr) *UpdateExpr {
+	return Update(table).With(w)
+}
+
+// Delete 创建一个使用当前WITH子句的删除
+func (w *WithExpr) Delete(table Expr) *DeleteExpr {
+	return Delete(table).With(w)
+}
+
+func (w *WithExpr) Format(buffer *TracedBuffer) {
+	if w.Recursive {
+		buffer.AppendKeyword(keywords.WithRecursive)
+	} else {
+		buffer.AppendKeyword(keywords.With)
+	}
+	buffer.AppendString(keywords.Space)
+	for idx, cte := range w.CTEs {
+		if idx > 0 {
+			buffer.AppendString(", ")
+		}
+		cte.Format(buffer)
+	}
+}
+
+// With 创建WITH子句，例如：With("t", Select(N("id")).From(N("user"))).Select(All).From(N("t"))
+func With(name string, query Expr, columns ...string) *WithExpr {
+	return (&WithExpr{}).As(name, query, columns...)
+}
+
+// WithRecursive 创建WITH RECURSIVE子句
+func WithRecursive(name string, query Expr, columns ...string) *WithExpr {
+	return (&WithExpr{Recursive: true}).As(name, query, columns...)
+}
+
+// formatWith 格式化语句前的WITH子句
+func formatWith(w *WithExpr, buffer *TracedBuffer) {
+	if w != nil && len(w.CTEs) > 0 {
+		w.Format(buffer)
+		buffer.AppendString(keywords.Space)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package expr
+
+import (
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	orgQuery := List(" UNION ALL ",
+		Select(N("id"), N("parent_id")).From(N("org")).Where(Eq(N("id"), Const(1))),
+		Select(N("id", "o"), N("parent_id", "o")).From(Alias(N("org"), "o")).
+			InnerJoin(Alias(N("tree"), "t"), Eq(N("parent_id", "o"), N("id", "t"))),
+	)
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    Expr
+		want    string
+	}{
+		{
+			name:    "simple",
+			dialect: dialect.MySQL,
+			expr:    With("admins", Select(All).From(N("user")).Where(Eq(N("role"), Const("admin")))).Select(All).From(N("admins")),
+			want:    "WITH `admins` AS (SELECT * FROM `user` WHERE `role` = 'admin') SELECT * FROM `admins`",
+		}, {
+			name:    "multiple",
+			dialect: dialect.MySQL,
+			expr: With("a", Select(N("id")).From(N("user"))).As("b", Select(N("id")).From(N("role"))).
+				Select(All).From(N("a")).InnerJoin(N("b"), Eq(N("id", "a"), N("id", "b"))),
+			want: "WITH `a` AS (SELECT `id` FROM `user`), `b` AS (SELECT `id` FROM `role`) SELECT * FROM `a` INNER JOIN `b` ON `a`.`id` = `b`.`id`",
+		}, {
+			name:    "recursive",
+			dialect: dialect.Postgres,
+			expr:    WithRecursive("tree", orgQuery, "id", "parent_id").Select(All).From(N("tree")),
+			want:    `WITH RECURSIVE "tree"("id","parent_id") AS (SELECT "id","parent_id" FROM "org" WHERE "id" = 1 UNION ALL SELECT "o"."id","o"."parent_id" FROM "org" AS "o" INNER JOIN "tree" AS "t" ON "o"."parent_id" = "t"."id") SELECT * FROM "tree"`,
+		}, {
+			name:    "recursive(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    WithRecursive("tree", Select(N("id")).From(N("org")), "id").Select(All).From(N("tree")),
+			want:    "WITH [tree]([id]) AS (SELECT [id] FROM [org]) SELECT * FROM [tree]",
+		}, {
+			name:    "update",
+			dialect: dialect.MySQL,
+			expr:    With("t", Select(N("id")).From(N("user"))).Update(N("role")).Set(N("desc").Eq(Const("x"))).Where(N("id").In(Select(N("id")).From(N("t")))),
+			want:    "WITH `t` AS (SELECT `id` FROM `user`) UPDATE `role` SET `desc` = 'x' WHERE `id` IN (SELECT `id` FROM `t`)",
+		}, {
+			name:    "delete",
+			dialect: dialect.MySQL,
+			expr:    With("t", Select(N("id")).From(N("user"))).Delete(N("role")).Where(N("id").In(Select(N("id")).From(N("t")))),
+			want:    "WITH `t` AS (SELECT `id` FROM `user`) DELETE FROM `role` WHERE `id` IN (SELECT `id` FROM `t`)",
+		}, {
+			name:    "count",
+			dialect: dialect.MySQL,
+			expr:    With("t", Select(N("id")).From(N("user"))).Select(All).From(N("t")).Limit(10).BuildCountExpr(),
+			want:    "WITH `t` AS (SELECT `id` FROM `user`) SELECT COUNT(1) FROM `t`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := NewTracedBuffer(tt.dialect)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/cookieY/sqlx"
+	"github.com/gnodux/sqlmx/dialect"
+)
+
+// fakeHandler 测试驱动中执行语句的处理函数，返回结果集的列和行
+type fakeHandler func(query string, args []driver.Value) (columns []string, rows [][]driver.Value, err error)
+
+var (
+	fakeHandlers = map[string]fakeHandler{}
+	fakeLock     sync.Mutex
+)
+
+func init() {
+	sql.Register("sqlmx_fake", fakeDriver{})
+}
+
+// newFakeDB 使用测试驱动创建数据库，handler处理所有的查询和执行
+func newFakeDB(t *testing.T, d *dialect.Dialect, handler fakeHandler) *DB {
+	fakeLock.Lock()
+	fakeHandlers[t.Name()] = handler
+	fakeLock.Unlock()
+	db := &DB{DB: sqlx.NewDb(sql.OpenDB(fakeConnector{dsn: t.Name()}), d.Name), driver: d}
+	db.MapperFunc(NameFunc)
+	db.SetTemplate(template.New("sql").Funcs(MakeFuncMap(d)))
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	return &fakeConn{dsn: dsn}, nil
+}
+
+type fakeConnector struct {
+	dsn string
+}
+
+func (c fakeConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return &fakeConn{dsn: c.dsn}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeConn struct {
+	dsn string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c, nil
+}
+func (c *fakeConn) Commit() error   { return nil }
+func (c *fakeConn) Rollback() error { return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) run(args []driver.Value) ([]string, [][]driver.Value, error) {
+	fakeLock.Lock()
+	handler := fakeHandlers[s.conn.dsn]
+	fakeLock.Unlock()
+	return handler(s.query, args)
+}
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, rows, err := s.run(args)
+	if err != nil {
+		return nil, err
+	}
+	return fakeResult(len(rows)), nil
+}
+
+// fakeResult 执行结果，影响的行数为处理函数返回的行数，自增ID固定为1
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return int64(r), nil }
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	columns, rows, err := s.run(args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
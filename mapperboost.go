@@ -8,6 +8,7 @@ package sqlmx
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/gnodux/sqlmx/utils"
 	"path/filepath"
 	"reflect"
@@ -90,9 +91,31 @@ func parseExtTags(field reflect.StructField) (ds, tpl string, level sql.Isolatio
 	return
 }
 
+// checkResult 检查模版声明的结果数量(@result)与函数类型是否一致
+func checkResult(mapper reflect.Type, field reflect.StructField, fnType string, meta *TplMeta) error {
+	if meta == nil || meta.Result == "" {
+		return nil
+	}
+	var result string
+	switch fnType {
+	case "SelectFunc", "NamedSelectFunc", "NamedSelectArgFunc":
+		result = ResultMany
+	case "GetFunc", "NamedGetFunc", "NamedGetArgFunc":
+		result = ResultOne
+	default:
+		return nil
+	}
+	if result != meta.Result {
+		return fmt.Errorf("%s.%s: %s conflicts with template @%s %s", mapper.Name(), field.Name, fnType, MetaResult, meta.Result)
+	}
+	return nil
+}
+
 // BoostMapper 对mapper的Field进行wrap处理、绑定数据源、绑定sql模版、绑定事务级别、绑定是否只读等
 //
 // change: 2023-7-12 修改绑定策略，从延迟绑定修改到boost时绑定，动态打开数据库的需求不高，且模版延迟绑定和获取数据库需要使用到锁，对性能有一定影响
+//
+// DBManager开启严格模式(SetStrictMapper)时，绑定完成后检查mapper，所有问题合并为一个ErrInvalidMapper错误返回，参见validator
 func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 	currentDb, err := factory.Get(ds)
 	if err != nil {
@@ -109,6 +132,7 @@ func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 		ii.init()
 	}
 	v = v.Elem()
+	check := &validator{db: currentDb, mapper: v.Type(), strict: factory.strict}
 	for idx := 0; idx < v.Type().NumField(); idx++ {
 		field := v.Type().Field(idx)
 		fieldDs, sqlTpl, isoLevel, readonly := parseExtTags(field)
@@ -117,7 +141,9 @@ func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 		}
 		if field.IsExported() && field.Type.Kind() == reflect.Struct {
 			if err := BoostMapper(v.Field(idx).Addr().Interface(), factory, fieldDs); err != nil {
-				return err
+				if err = check.report(field, err); err != nil {
+					return err
+				}
 			}
 			continue
 		}
@@ -155,22 +181,34 @@ func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 					tplName := filepath.Join(NameFunc(v.Type().PkgPath()), NameFunc(v.Type().Name()),
 						NameFunc(field.Name)+sqlSuffix)
 					if _, err = currentDb.ParseTemplate(tplName, sqlTpl); err != nil {
-						return err
+						if err = check.report(field, err); err != nil {
+							return err
+						}
+						continue
 					}
 					sqlTpl = tplName
 				}
 				tplList = append(tplList, sqlTpl)
 			}
+			//used: 实际使用的模版(候选列表)，argType: 命名参数的类型
+			used, argType := tplList, reflect.Type(nil)
 			switch field.Type {
 			case ExecFuncType:
 				v.Field(idx).Set(reflect.ValueOf(NewExecFuncWith(currentDb, sqlTpl)))
+				used = []string{sqlTpl}
 			case NamedExecFuncType:
 				v.Field(idx).Set(reflect.ValueOf(NewNamedExecFuncWith(currentDb, sqlTpl)))
+				used = []string{sqlTpl}
 			case TxFuncType:
 				v.Field(idx).Set(reflect.ValueOf(NewTxFuncWith(currentDb, sqlTpl, &sql.TxOptions{
 					Isolation: isoLevel,
 					ReadOnly:  readonly,
 				})))
+				//事务的模版是可选的，只检查显式声明的模版
+				used = nil
+				if field.Tag.Get(TagSQL) != "" {
+					used = []string{sqlTpl}
+				}
 			default:
 				name := field.Type.Name()
 				//begin: 判断是否泛型，并去除泛型参数
@@ -179,6 +217,11 @@ func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 					name = name[:quotaIdx]
 				}
 				//end
+				if err = checkResult(v.Type(), field, name, currentDb.metaOf(getTpl(currentDb, tplList))); err != nil {
+					if err = check.report(field, err); err != nil {
+						return err
+					}
+				}
 				var fnVal func([]reflect.Value) []reflect.Value
 				switch name {
 				case "SelectFunc":
@@ -189,7 +232,7 @@ func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 							utils.ValueOrZero(err, field.Type.Out(1)),
 						}
 					}
-				case "NamedSelectFunc":
+				case "NamedSelectFunc", "NamedSelectArgFunc":
 					fnVal = func(values []reflect.Value) []reflect.Value {
 						ret, err := NamedSelectWith(field.Type.Out(0).Elem(), currentDb, tplList, values[0].Interface())
 						return []reflect.Value{
@@ -205,7 +248,7 @@ func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 							utils.ValueOrZero(err, field.Type.Out(1)),
 						}
 					}
-				case "NamedGetFunc":
+				case "NamedGetFunc", "NamedGetArgFunc":
 					fnVal = func(values []reflect.Value) []reflect.Value {
 						ret, err := NamedGetWith(field.Type.Out(0), currentDb, tplList, values[0].Interface())
 						return []reflect.Value{
@@ -213,14 +256,28 @@ func BoostMapper(dest interface{}, factory *DBManager, ds string) error {
 							utils.ValueOrZero(err, field.Type.Out(1)),
 						}
 					}
+				case "NamedExecArgFunc":
+					fnVal = func(values []reflect.Value) []reflect.Value {
+						ret, err := currentDb.NamedExecEx(getTpl(currentDb, tplList), values[0].Interface())
+						return []reflect.Value{
+							utils.ValueOrZero(ret, field.Type.Out(0)),
+							utils.ValueOrZero(err, field.Type.Out(1)),
+						}
+					}
+				}
+				if fnVal == nil {
+					check.unresolved(field)
+					continue
 				}
-				if fnVal != nil {
-					v.Field(idx).Set(reflect.MakeFunc(field.Type, fnVal))
+				v.Field(idx).Set(reflect.MakeFunc(field.Type, fnVal))
+				if strings.HasPrefix(name, "Named") {
+					argType = field.Type.In(0)
 				}
 			}
+			check.templates(field, used, argType)
 		}
 	}
-	return nil
+	return check.err()
 }
 
 // Boost 对mapper的Field进行wrap处理、绑定数据源、绑定sql模版、绑定事务级别、绑定是否只读等
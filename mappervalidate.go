@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/cookieY/sqlx/reflectx"
+)
+
+// ErrInvalidMapper 严格模式下mapper检查失败
+var ErrInvalidMapper = errors.New("invalid mapper")
+
+// validator 严格模式下检查mapper的绑定结果，收集所有问题后一起返回
+type validator struct {
+	db     *DB
+	mapper reflect.Type
+	strict bool
+	errs   []error
+}
+
+// report 记录绑定过程中的错误，非严格模式下返回错误并中止绑定
+func (c *validator) report(field reflect.StructField, err error) error {
+	if !c.strict {
+		return err
+	}
+	c.errs = append(c.errs, fmt.Errorf("%s: %w", field.Name, err))
+	return nil
+}
+
+// unresolved 无法识别的函数类型，字段保持为nil
+func (c *validator) unresolved(field reflect.StructField) {
+	if c.strict {
+		c.errs = append(c.errs, fmt.Errorf("%s: unsupported func type %s", field.Name, field.Type))
+	}
+}
+
+// templates 检查候选模版中是否存在可用的模版，argType不为nil时检查模版中的命名参数
+func (c *validator) templates(field reflect.StructField, tplList []string, argType reflect.Type) {
+	if !c.strict || len(tplList) == 0 {
+		return
+	}
+	var tpl string
+	for _, name := range tplList {
+		if tpl = c.db.LookupTemplate(name); tpl != "" {
+			break
+		}
+	}
+	if tpl == "" {
+		c.errs = append(c.errs, fmt.Errorf("%s: template not found: %s", field.Name, strings.Join(tplList, ", ")))
+		return
+	}
+	if argType == nil {
+		return
+	}
+	//map和any无法在启动时检查
+	t := reflectx.Deref(argType)
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	fields := c.db.mapper().TypeMap(t)
+	for _, name := range namedParams(c.db.Template().Lookup(tpl)) {
+		if fields.GetByPath(name) == nil {
+			c.errs = append(c.errs, fmt.Errorf("%s: named parameter :%s not found in %s", field.Name, name, argType))
+		}
+	}
+}
+
+func (c *validator) err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w %s:\n%w", ErrInvalidMapper, c.mapper, errors.Join(c.errs...))
+}
+
+// mapper 命名参数与结构体字段的映射，与sqlx绑定命名参数时一致
+func (d *DB) mapper() *reflectx.Mapper {
+	if d.DB != nil && d.DB.Mapper != nil {
+		return d.DB.Mapper
+	}
+	return reflectx.NewMapperFunc("db", NameFunc)
+}
+
+// namedParams 模版中引用的命名参数(:name)，忽略字符串、注释和Postgres的类型转换(::)
+func namedParams(tpl *template.Template) []string {
+	if tpl == nil || tpl.Tree == nil {
+		return nil
+	}
+	sb := &strings.Builder{}
+	templateText(tpl.Tree.Root, sb)
+	text := sb.String()
+	var names []string
+	seen := map[string]bool{}
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\'', '"', '`':
+			end := strings.IndexByte(text[i+1:], c)
+			if end < 0 {
+				return names
+			}
+			i += end + 1
+		case '-':
+			if strings.HasPrefix(text[i:], "--") {
+				end := strings.IndexByte(text[i:], '\n')
+				if end < 0 {
+					return names
+				}
+				i += end
+			}
+		case ':':
+			if i+1 < len(text) && text[i+1] == ':' {
+				i++
+				continue
+			}
+			end := i + 1
+			for end < len(text) && isNameChar(text[end]) {
+				end++
+			}
+			name := strings.TrimRight(text[i+1:end], ".")
+			//数字开头的不是参数，例如数组切片arr[1:2]
+			if name != "" && (name[0] < '0' || name[0] > '9') && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			i = end - 1
+		}
+	}
+	return names
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// templateText 模版中的SQL文本，模版动作替换为占位符
+func templateText(node parse.Node, sb *strings.Builder) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			templateText(child, sb)
+		}
+	case *parse.TextNode:
+		sb.Write(n.Text)
+	case *parse.IfNode:
+		templateText(n.List, sb)
+		templateText(n.ElseList, sb)
+	case *parse.RangeNode:
+		templateText(n.List, sb)
+		templateText(n.ElseList, sb)
+	case *parse.WithNode:
+		templateText(n.List, sb)
+		templateText(n.ElseList, sb)
+	case *parse.ActionNode, *parse.TemplateNode:
+		sb.WriteByte('?')
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+type strictUserArg struct {
+	Name   string
+	MinAge int
+}
+
+type strictMapper struct {
+	Count      GetFunc[int]                            `sql:"SELECT COUNT(*) FROM user"`
+	ListByName NamedSelectArgFunc[strictUserArg, int]  `sql:"SELECT id FROM user WHERE name=:name AND age>:min_age AND created_at::date='2023-01-01 12:00' -- :comment"`
+	ListByRole NamedSelectArgFunc[*strictUserArg, int] `sql:"SELECT id FROM user WHERE role=:role"`
+	GetByName  NamedGetArgFunc[map[string]any, int]    `sql:"SELECT id FROM user WHERE name=:whatever"`
+	Update     NamedExecArgFunc[strictUserArg]         `sql:"UPDATE user SET age=:min_age WHERE name=:name"`
+	Broken     SelectFunc[int]                         `sql:"SELECT {{if}} FROM user"`
+	GetMissing GetFunc[int]
+	ListUser   SelectFunc[int] `sql:"strict/list_user.sql"`
+	Unknown    func() int
+}
+
+func TestStrictMapper(t *testing.T) {
+	d := &DB{driver: dialect.MySQL}
+	d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(dialect.MySQL)))
+	_, err := d.ParseTemplate("strict/list_user.sql", "SELECT id FROM user WHERE id IN ({{bind .}})")
+	assert.NoError(t, err)
+	m := NewDBManager("strict")
+	m.Set("db", d)
+
+	//非严格模式下inline SQL解析失败立即返回
+	_, err = NewMapperWith[strictMapper](m, "db")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidMapper)
+
+	m.SetStrictMapper(true)
+	mapper, err := NewMapperWith[strictMapper](m, "db")
+	assert.ErrorIs(t, err, ErrInvalidMapper)
+	for _, want := range []string{
+		"Broken: ",
+		"GetMissing: template not found",
+		"ListByRole: named parameter :role not found in *sqlmx.strictUserArg",
+		"Unknown: unsupported func type func() int",
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+	for _, notWant := range []string{"Count:", "ListByName:", "GetByName:", "Update:", "ListUser:", ":comment"} {
+		assert.NotContains(t, err.Error(), notWant)
+	}
+	assert.NotNil(t, mapper.ListByName)
+	assert.NotNil(t, mapper.Update)
+	assert.Nil(t, mapper.Unknown)
+}
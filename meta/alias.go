@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package meta
+
+import (
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/expr/keywords"
+)
+
+// AliasedEntity 使用别名的实体，作为表来源时格式化为：`user` AS `u`，列使用别名限定：`u`.`id`
+type AliasedEntity struct {
+	Entity *Entity
+	Alias  string
+}
+
+func (a *AliasedEntity) Format(buffer *expr.TracedBuffer) {
+	a.Entity.Format(buffer)
+	buffer.AppendKeywordWithSpace(keywords.AS)
+	buffer.AppendString(buffer.SQLNameFunc(a.Alias))
+}
+
+// TableAlias 实现expr.AliasedTable，方言不支持USING时使用别名生成ON条件
+func (a *AliasedEntity) TableAlias() string {
+	return a.Alias
+}
+
+// Col 使用别名限定的列，name可以是字段名称或列名，例如：Col("UserName") => `u`.`user_name`
+func (a *AliasedEntity) Col(name string) *expr.NameExpr {
+	return expr.Name(a.Entity.columnRef(name), a.Alias)
+}
+
+// All 别名下的所有列：`u`.*
+func (a *AliasedEntity) All() *expr.NameExpr {
+	return expr.Name(keywords.All, a.Alias)
+}
+
+// ColumnExprs 返回使用别名限定的所有列
+func (a *AliasedEntity) ColumnExprs() []expr.Expr {
+	var exprs []expr.Expr
+	for _, col := range a.Entity.Columns {
+		exprs = append(exprs, expr.Name(col.ColumnName, a.Alias))
+	}
+	return exprs
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package meta
+
+import (
+	"testing"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/expr/keywords"
+	"github.com/stretchr/testify/assert"
+)
+
+type employee struct {
+	Id        int64
+	Name      string
+	ManagerId int64
+	TenantId  int64
+}
+
+func TestAliasedEntity(t *testing.T) {
+	entity := NewEntity(&employee{})
+	e, m := entity.As("e"), entity.As("m")
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		expr    expr.Expr
+		want    string
+	}{
+		{
+			name:    "self join",
+			dialect: dialect.MySQL,
+			expr: expr.Select(e.All(), m.Col("Name").As("manager")).From(e).
+				LeftJoin(m, e.Col("ManagerId").Eq(m.Col("id"))).
+				Where(e.Col("name").Like(expr.Var("name", "a%"))),
+			want: "SELECT `e`.*,`m`.`name` AS `manager` FROM `employee` AS `e` LEFT JOIN `employee` AS `m` ON `e`.`manager_id` = `m`.`id` WHERE `e`.`name` LIKE :name",
+		}, {
+			name:    "table and alias",
+			dialect: dialect.Postgres,
+			expr:    expr.Select(m.ColumnExprs()...).From(entity).InnerJoin(m, entity.Col("ManagerId").Eq(m.Col("Id"))),
+			want:    `SELECT "m"."id","m"."name","m"."manager_id","m"."tenant_id" FROM "employee" INNER JOIN "employee" AS "m" ON "employee"."manager_id" = "m"."id"`,
+		}, {
+			name:    "using(sql server)",
+			dialect: dialect.SQLServer,
+			expr:    expr.Select(e.Col("id")).From(e).Join(expr.JoinUsing(keywords.InnerJoin, m, expr.N("tenant_id"))),
+			want:    "SELECT [e].[id] FROM [employee] AS [e] INNER JOIN [employee] AS [m] ON [e].[tenant_id] = [m].[tenant_id]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := expr.NewTracedBuffer(tt.dialect)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
@@ -18,6 +18,7 @@ const (
 	MarkIgnore    = "_"
 	MarkTenantKey = "tenantKey"
 	MarkIsDeleted = "softDelete"
+	MarkUnique    = "unique"
 )
 
 var ()
@@ -34,6 +35,9 @@ type Entity struct {
 	PrimaryKey     *Column
 	TenantKey      *Column
 	LogicDeleteKey *Column
+	UniqueKeys     []*Column
+	//UniqueGroups 唯一键，每个元素是一个(联合)唯一键包含的列，按照声明的顺序排列
+	UniqueGroups [][]*Column
 }
 
 func (m *Entity) String() string {
@@ -51,6 +55,42 @@ func (m *Entity) ColumnExprs() []expr.Expr {
 	return exprs
 }
 
+// QualifiedColumnExprs 返回使用表名限定的列表达式，用于连接查询时避免列名冲突
+func (m *Entity) QualifiedColumnExprs() []expr.Expr {
+	var exprs []expr.Expr
+	for _, col := range m.Columns {
+		exprs = append(exprs, expr.Name(col.ColumnName, m.TableName))
+	}
+	return exprs
+}
+
+// Col 使用表名限定的列，name可以是字段名称或列名，例如：Col("UserName") => `user`.`user_name`
+func (m *Entity) Col(name string) *expr.NameExpr {
+	return expr.Name(m.columnRef(name), m.TableName)
+}
+
+// As 使用别名作为表来源，用于连接查询和自连接，例如：
+//
+//	p := userEntity.As("p")
+//	expr.Select(userEntity.Col("name"), p.Col("name")).From(userEntity).LeftJoin(p, userEntity.Col("parent_id").Eq(p.Col("id")))
+func (m *Entity) As(alias string) *AliasedEntity {
+	return &AliasedEntity{Entity: m, Alias: alias}
+}
+
+// ConflictKeys 返回upsert时的冲突检测列，优先使用第一个唯一键，没有唯一键时使用主键
+//
+// 冲突检测列必须与数据库中的一个唯一索引完全一致(Postgres的ON CONFLICT)，联合唯一键使用dbx:"unique=分组名称"声明，
+// 例如：TenantId和Name都声明为dbx:"unique=uk_name"时冲突检测列为(tenant_id,name)
+func (m *Entity) ConflictKeys() []*Column {
+	if len(m.UniqueGroups) > 0 {
+		return m.UniqueGroups[0]
+	}
+	if m.PrimaryKey != nil {
+		return []*Column{m.PrimaryKey}
+	}
+	return nil
+}
+
 // ColumnName return column name by field name
 func (m *Entity) ColumnName(name string) string {
 	for _, col := range m.Columns {
@@ -61,6 +101,14 @@ func (m *Entity) ColumnName(name string) string {
 	return name
 }
 
+// columnRef 字段名称或列名对应的列名，列不存在时返回name
+func (m *Entity) columnRef(name string) string {
+	if col := m.Column(name); col != nil {
+		return col.ColumnName
+	}
+	return name
+}
+
 func (m *Entity) Column(name string) *Column {
 	for _, col := range m.Columns {
 		if col.Name == name || col.ColumnName == name {
@@ -77,7 +125,10 @@ type Column struct {
 	IsPrimaryKey     bool
 	IsTenantKey      bool
 	IsLogicDeleteKey bool
-	Ignore           bool
+	IsUnique         bool
+	//UniqueGroup 唯一键分组，相同分组的列组成联合唯一键，为空时该列单独作为唯一键
+	UniqueGroup string
+	Ignore      bool
 }
 
 func (c *Column) String() string {
@@ -88,6 +139,19 @@ func (c *Column) Format(buffer *expr.TracedBuffer) {
 	buffer.AppendString(buffer.SQLNameFunc(c.ColumnName))
 }
 
+// IsIdentity 是否为自增主键(整数类型的主键)，自增主键由数据库生成，插入后回填
+func (c *Column) IsIdentity() bool {
+	if !c.IsPrimaryKey || c.Type == nil {
+		return false
+	}
+	switch c.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
 func NewEntity(v any) *Entity {
 	meta := &Entity{
 		TableName: GetTableName(v),
@@ -105,11 +169,34 @@ func NewEntity(v any) *Entity {
 		if col.IsLogicDeleteKey {
 			meta.LogicDeleteKey = col
 		}
+		if col.IsUnique {
+			meta.UniqueKeys = append(meta.UniqueKeys, col)
+		}
 		return true
 	})
+	meta.UniqueGroups = uniqueGroups(meta.UniqueKeys)
 	return meta
 }
 
+// uniqueGroups 按照分组名称组合唯一键，没有分组名称的列单独作为唯一键
+func uniqueGroups(cols []*Column) [][]*Column {
+	var groups [][]*Column
+	index := map[string]int{}
+	for _, col := range cols {
+		if col.UniqueGroup == "" {
+			groups = append(groups, []*Column{col})
+			continue
+		}
+		if idx, ok := index[col.UniqueGroup]; ok {
+			groups[idx] = append(groups[idx], col)
+			continue
+		}
+		index[col.UniqueGroup] = len(groups)
+		groups = append(groups, []*Column{col})
+	}
+	return groups
+}
+
 func ListValueColumns(v any) []*Column {
 	argv := reflect.TypeOf(v)
 	return ListColumns(argv)
@@ -135,6 +222,8 @@ func ListColumns(t reflect.Type) []*Column {
 func parseTags(col *Column, tags string) {
 	tagList := strings.Split(tags, ",")
 	for _, tag := range tagList {
+		//带参数的标记，例如：unique=uk_name
+		tag, value, _ := strings.Cut(tag, "=")
 		switch tag {
 		case MarkPK:
 			col.IsPrimaryKey = true
@@ -144,6 +233,9 @@ func parseTags(col *Column, tags string) {
 			col.IsTenantKey = true
 		case MarkIsDeleted:
 			col.IsLogicDeleteKey = true
+		case MarkUnique:
+			col.IsUnique = true
+			col.UniqueGroup = value
 		}
 	}
 }
@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package meta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConflictKeys(t *testing.T) {
+	type Account struct {
+		Id       int64
+		TenantId int64  `dbx:"unique=uk_name"`
+		Name     string `dbx:"unique=uk_name"`
+		Email    string `dbx:"unique"`
+	}
+	type Tag struct {
+		Id   string
+		Name string
+	}
+	account := NewEntity(&Account{})
+	assert.Equal(t, [][]*Column{
+		{account.Column("TenantId"), account.Column("Name")},
+		{account.Column("Email")},
+	}, account.UniqueGroups)
+	assert.Equal(t, []*Column{account.Column("TenantId"), account.Column("Name")}, account.ConflictKeys())
+	assert.True(t, account.PrimaryKey.IsIdentity())
+
+	tag := NewEntity(&Tag{})
+	assert.Equal(t, []*Column{tag.PrimaryKey}, tag.ConflictKeys())
+	assert.False(t, tag.PrimaryKey.IsIdentity())
+}
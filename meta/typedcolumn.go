@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package meta
+
+import (
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/utils"
+)
+
+// TypedColumn 带有Go类型的列，比较的值在编译时检查类型，例如：UserCols.Name.Eq("tom")
+//
+// TypedColumn可以像*Column一样作为表达式使用，通常由colgen根据实体结构体生成
+type TypedColumn[V any] struct {
+	*Column
+}
+
+// NewTypedColumn 根据字段名称或列名创建带类型的列
+//
+// 列不存在时(生成的代码与实体不一致)按照字段名称的默认规则生成列名，不会在初始化时panic，
+// 以免过期的生成代码导致生成器本身无法运行
+func NewTypedColumn[V any](entity *Entity, name string) TypedColumn[V] {
+	col := entity.Column(name)
+	if col == nil {
+		col = &Column{Name: name, ColumnName: utils.LowerCase(name)}
+	}
+	return TypedColumn[V]{Column: col}
+}
+
+func (c TypedColumn[V]) bind(v V) *expr.ValueExpr {
+	return expr.Var(c.ColumnName, v)
+}
+
+// Eq `col` = :col
+func (c TypedColumn[V]) Eq(v V) *expr.BinaryExpr {
+	return expr.Eq(c.Column, c.bind(v))
+}
+
+// Ne `col` != :col
+func (c TypedColumn[V]) Ne(v V) *expr.BinaryExpr {
+	return expr.Ne(c.Column, c.bind(v))
+}
+
+// Gt `col` > :col
+func (c TypedColumn[V]) Gt(v V) *expr.BinaryExpr {
+	return expr.Gt(c.Column, c.bind(v))
+}
+
+// Ge `col` >= :col
+func (c TypedColumn[V]) Ge(v V) *expr.BinaryExpr {
+	return expr.Ge(c.Column, c.bind(v))
+}
+
+// Lt `col` < :col
+func (c TypedColumn[V]) Lt(v V) *expr.BinaryExpr {
+	return expr.Lt(c.Column, c.bind(v))
+}
+
+// Le `col` <= :col
+func (c TypedColumn[V]) Le(v V) *expr.BinaryExpr {
+	return expr.Le(c.Column, c.bind(v))
+}
+
+// Like `col` LIKE :col
+func (c TypedColumn[V]) Like(pattern string) *expr.BinaryExpr {
+	return expr.Like(c.Column, expr.Var(c.ColumnName, pattern))
+}
+
+// In `col` IN (:col_0,:col_1...)
+func (c TypedColumn[V]) In(values ...V) *expr.BinaryExpr {
+	return expr.In(c.Column, c.ColumnName, anys(values)...)
+}
+
+// NotIn `col` NOT IN (:col_0,:col_1...)
+func (c TypedColumn[V]) NotIn(values ...V) *expr.BinaryExpr {
+	return expr.NotIn(c.Column, c.ColumnName, anys(values)...)
+}
+
+// Between `col` BETWEEN :col_min AND :col_max
+func (c TypedColumn[V]) Between(min, max V) *expr.BetweenExpr {
+	return expr.Between(c.Column, expr.Var(c.ColumnName+"_min", min), expr.Var(c.ColumnName+"_max", max))
+}
+
+// IsNull `col` IS NULL
+func (c TypedColumn[V]) IsNull() *expr.BinaryExpr {
+	return expr.IsNull(c.Column)
+}
+
+// IsNotNull `col` IS NOT NULL
+func (c TypedColumn[V]) IsNotNull() *expr.BinaryExpr {
+	return expr.IsNotNull(c.Column)
+}
+
+// EqCol 与相同类型的另一列比较：`col` = `other`
+func (c TypedColumn[V]) EqCol(other TypedColumn[V]) *expr.BinaryExpr {
+	return expr.Eq(c.Column, other.Column)
+}
+
+// Set 更新语句中的赋值：`col` = :col，例如：expr.Set(UserCols.Name.Set("tom"))
+func (c TypedColumn[V]) Set(v V) *expr.BinaryExpr {
+	return expr.Eq(c.Column, c.bind(v))
+}
+
+// Asc 升序
+func (c TypedColumn[V]) Asc() expr.Expr {
+	return expr.Asc(c.Column)
+}
+
+// Desc 降序
+func (c TypedColumn[V]) Desc() expr.Expr {
+	return expr.Desc(c.Column)
+}
+
+func anys[V any](values []V) []any {
+	result := make([]any, 0, len(values))
+	for _, v := range values {
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package meta
+
+import (
+	"testing"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+type account struct {
+	Id       int64
+	UserName string
+	Age      int
+}
+
+func TestTypedColumn(t *testing.T) {
+	entity := NewEntity(&account{})
+	id := NewTypedColumn[int64](entity, "Id")
+	name := NewTypedColumn[string](entity, "UserName")
+	age := NewTypedColumn[int](entity, "age")
+	tests := []struct {
+		name string
+		expr expr.Expr
+		want string
+	}{
+		{
+			name: "select",
+			expr: expr.Select(id, name).From(entity).
+				Where(expr.And(name.Like("tom%"), age.Between(18, 60), id.In(1, 2))).
+				OrderBy(age.Desc()),
+			want: "SELECT `id`,`user_name` FROM `account` WHERE `user_name` LIKE :user_name AND `age` BETWEEN :age_min AND :age_max AND `id` IN ( :id_0,:id_1 ) ORDER BY `age` DESC",
+		}, {
+			name: "update",
+			expr: expr.Update(entity).Set(name.Set("jerry")).Where(expr.And(id.Eq(1), age.IsNotNull())),
+			want: "UPDATE `account` SET `user_name` = :user_name WHERE `id` = :id AND `age` IS NOT NULL",
+		}, {
+			name: "missing column",
+			expr: NewTypedColumn[string](entity, "Email").Eq("a@b.c"),
+			want: "`email` = :email",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := expr.NewTracedBuffer(dialect.MySQL)
+			tt.expr.Format(buf)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
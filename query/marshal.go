@@ -0,0 +1,247 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/expr/keywords"
+	"github.com/gnodux/sqlmx/meta"
+)
+
+// SQL运算符和比较运算符的对应关系
+var sqlOps = map[string]string{
+	keywords.Equal:        OpEq,
+	"<>":                  OpNe,
+	keywords.NotEqual:     OpNe,
+	keywords.Greater:      OpGt,
+	keywords.GreaterEqual: OpGe,
+	keywords.Less:         OpLt,
+	keywords.LessEqual:    OpLe,
+	keywords.Like:         OpLike,
+}
+
+// Marshal 将表达式序列化为JSON格式的查询
+//
+// exp可以是*expr.SelectExpr(使用其中的条件、排序和分页)或者条件表达式，
+// 无法使用查询表示的表达式(原始SQL、函数、子查询、限定的列名等)返回ErrUnsupportedExpr
+func Marshal(exp expr.Expr) ([]byte, error) {
+	q, err := FromExpr(exp)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(q)
+}
+
+// FromExpr 将表达式转换为查询
+func FromExpr(exp expr.Expr) (*Query, error) {
+	q := &Query{}
+	s, ok := exp.(*expr.SelectExpr)
+	if !ok {
+		cond, err := toCond(exp)
+		if err != nil {
+			return nil, err
+		}
+		q.Where = cond
+		return q, nil
+	}
+	if s.WhereExpr != nil {
+		cond, err := toCond(s.WhereExpr)
+		if err != nil {
+			return nil, err
+		}
+		q.Where = cond
+	}
+	if s.OrderByExpr != nil {
+		sort, err := toOrders(s.OrderByExpr)
+		if err != nil {
+			return nil, err
+		}
+		q.Sort = sort
+	}
+	q.Limit, q.Offset = s.Pagination()
+	return q, nil
+}
+
+func unsupported(exp expr.Expr) error {
+	return fmt.Errorf("%w: %T", ErrUnsupportedExpr, exp)
+}
+
+func toCond(exp expr.Expr) (*Cond, error) {
+	switch e := exp.(type) {
+	case *expr.AroundExpr:
+		if e.Prefix == expr.LeftBracket && e.Suffix == expr.RightBracket {
+			return toCond(e.Expr)
+		}
+	case *expr.ListExpr:
+		var op string
+		switch e.Separator {
+		case keywords.And:
+			op = OpAnd
+		case keywords.Or:
+			op = OpOr
+		default:
+			return nil, unsupported(exp)
+		}
+		cond := &Cond{Op: op}
+		for _, item := range e.ExprList {
+			sub, err := toCond(item)
+			if err != nil {
+				return nil, err
+			}
+			cond.Conds = append(cond.Conds, sub)
+		}
+		return cond, nil
+	case *expr.UnaryExpr:
+		if e.Operator == keywords.Not {
+			sub, err := toCond(e.Expr)
+			if err != nil {
+				return nil, err
+			}
+			return &Cond{Op: OpNot, Conds: []*Cond{sub}}, nil
+		}
+	case *expr.BetweenExpr:
+		field, err := toField(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		start, err := toValue(e.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := toValue(e.End)
+		if err != nil {
+			return nil, err
+		}
+		return &Cond{Op: OpBetween, Field: field, Values: []any{start, end}}, nil
+	case *expr.BinaryExpr:
+		return binaryCond(e)
+	}
+	return nil, unsupported(exp)
+}
+
+func binaryCond(e *expr.BinaryExpr) (*Cond, error) {
+	field, err := toField(e.Left)
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToUpper(e.Operator)
+	switch op {
+	case keywords.Is, keywords.IsNot:
+		if e.Right != expr.NULL {
+			return nil, unsupported(e.Right)
+		}
+		if op == keywords.Is {
+			return &Cond{Op: OpNull, Field: field}, nil
+		}
+		return &Cond{Op: OpNotNull, Field: field}, nil
+	case keywords.In, keywords.NotIn:
+		values, err := toValues(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		if op == keywords.In {
+			return &Cond{Op: OpIn, Field: field, Values: values}, nil
+		}
+		return &Cond{Op: OpNotIn, Field: field, Values: values}, nil
+	}
+	cmp, ok := sqlOps[op]
+	if !ok {
+		return nil, fmt.Errorf("%w: operator %s", ErrUnsupportedExpr, e.Operator)
+	}
+	value, err := toValue(e.Right)
+	if err != nil {
+		return nil, err
+	}
+	return &Cond{Op: cmp, Field: field, Value: value}, nil
+}
+
+// toField 只支持未限定的列名
+func toField(exp expr.Expr) (string, error) {
+	switch e := exp.(type) {
+	case *meta.Column:
+		return e.ColumnName, nil
+	case *expr.NameExpr:
+		if len(e.Qualifier) == 0 && e.Name != keywords.All {
+			return e.Name, nil
+		}
+	}
+	return "", unsupported(exp)
+}
+
+func toValue(exp expr.Expr) (any, error) {
+	switch e := exp.(type) {
+	case *expr.ValueExpr:
+		return e.Value, nil
+	case *expr.ConstantExpr:
+		return e.Value, nil
+	}
+	return nil, unsupported(exp)
+}
+
+// toValues IN的值列表：( v1,v2 )
+func toValues(exp expr.Expr) ([]any, error) {
+	paren, ok := exp.(*expr.AroundExpr)
+	if !ok || paren.Prefix != expr.LeftBracket {
+		return nil, unsupported(exp)
+	}
+	items := []expr.Expr{paren.Expr}
+	if l, ok := paren.Expr.(*expr.ListExpr); ok && l.Separator == keywords.Comma {
+		items = l.ExprList
+	}
+	var values []any
+	for _, item := range items {
+		v, err := toValue(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// toOrders 排序列表：`a` ASC,`b` DESC
+func toOrders(exp expr.Expr) ([]*Order, error) {
+	items := []expr.Expr{exp}
+	if l, ok := exp.(*expr.ListExpr); ok && l.Separator == keywords.Comma {
+		items = l.ExprList
+	}
+	var orders []*Order
+	for _, item := range items {
+		order, err := toOrder(item)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func toOrder(exp expr.Expr) (*Order, error) {
+	if l, ok := exp.(*expr.ListExpr); ok && l.Separator == keywords.Space && len(l.ExprList) == 2 {
+		field, err := toField(l.ExprList[0])
+		if err != nil {
+			return nil, err
+		}
+		if direction, ok := l.ExprList[1].(*expr.RawExpr); ok {
+			switch direction.Value {
+			case keywords.Asc:
+				return &Order{Field: field}, nil
+			case keywords.Desc:
+				return &Order{Field: field, Desc: true}, nil
+			}
+		}
+		return nil, unsupported(l.ExprList[1])
+	}
+	field, err := toField(exp)
+	if err != nil {
+		return nil, err
+	}
+	return &Order{Field: field}, nil
+}
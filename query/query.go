@@ -0,0 +1,341 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+// Package query 可以在网络上传输的查询：过滤条件、排序和分页
+//
+// 查询使用稳定的JSON格式，例如：
+//
+//	{
+//	  "where": {"op": "and", "conds": [
+//	    {"op": "like", "field": "name", "value": "tom%"},
+//	    {"op": "in", "field": "role", "values": ["admin", "root"]}
+//	  ]},
+//	  "sort": [{"field": "created_at", "desc": true}],
+//	  "limit": 10,
+//	  "offset": 20
+//	}
+//
+// 来自客户端的查询必须使用meta.Entity校验，字段只能是实体中的列，值只能是字符串、数字、布尔值或null，
+// 生成的表达式中所有的值均使用参数绑定，不会出现原始SQL
+//
+// HTTP查询字符串形式的过滤条件使用ParseValues解析，例如：?name__like=ab%&age__gte=18&sort=-created_at&page=2
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/expr/keywords"
+	"github.com/gnodux/sqlmx/meta"
+)
+
+// 条件运算符
+const (
+	OpAnd     = "and"
+	OpOr      = "or"
+	OpNot     = "not"
+	OpEq      = "eq"
+	OpNe      = "ne"
+	OpGt      = "gt"
+	OpGe      = "ge"
+	OpLt      = "lt"
+	OpLe      = "le"
+	OpLike    = "like"
+	OpIn      = "in"
+	OpNotIn   = "nin"
+	OpBetween = "between"
+	OpNull    = "null"
+	OpNotNull = "notnull"
+)
+
+var (
+	//ErrInvalidQuery 查询不合法：未知的运算符、不允许的字段、错误的参数等
+	ErrInvalidQuery = errors.New("invalid query")
+	//ErrUnsupportedExpr 表达式无法转换为查询，例如原始SQL、函数、子查询等
+	ErrUnsupportedExpr = errors.New("unsupported expression")
+)
+
+// 比较运算符和SQL运算符的对应关系
+var binaryOps = map[string]string{
+	OpEq:   keywords.Equal,
+	OpNe:   keywords.NotEqual,
+	OpGt:   keywords.Greater,
+	OpGe:   keywords.GreaterEqual,
+	OpLt:   keywords.Less,
+	OpLe:   keywords.LessEqual,
+	OpLike: keywords.Like,
+}
+
+// Query 查询条件、排序和分页
+type Query struct {
+	Where  *Cond    `json:"where,omitempty"`
+	Sort   []*Order `json:"sort,omitempty"`
+	Limit  int      `json:"limit,omitempty"`
+	Offset int      `json:"offset,omitempty"`
+	entity *meta.Entity
+}
+
+// Cond 查询条件
+//
+// and/or使用Conds，not使用Conds中的第一个条件，in/nin/between使用Values，null/notnull只需要Field，其他比较运算使用Value
+type Cond struct {
+	Op     string  `json:"op"`
+	Field  string  `json:"field,omitempty"`
+	Value  any     `json:"value,omitempty"`
+	Values []any   `json:"values,omitempty"`
+	Conds  []*Cond `json:"conds,omitempty"`
+}
+
+// Order 排序
+type Order struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// Unmarshal 解析JSON格式的查询，并使用entity校验
+func Unmarshal(data []byte, entity *meta.Entity) (*Query, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	q := &Query{}
+	if err := decoder.Decode(q); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidQuery, err)
+	}
+	if err := q.Bind(entity); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Bind 使用entity校验查询，字段只能是entity中的列(字段名称或列名)，Limit不能超过MaxPageSize，校验通过后才能生成表达式
+func (q *Query) Bind(entity *meta.Entity) error {
+	if entity == nil {
+		return fmt.Errorf("%w: entity is required", ErrInvalidQuery)
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("%w: negative limit or offset", ErrInvalidQuery)
+	}
+	if q.Limit > MaxPageSize {
+		return fmt.Errorf("%w: limit %d exceeds %d", ErrInvalidQuery, q.Limit, MaxPageSize)
+	}
+	if q.Where != nil {
+		if err := q.Where.validate(entity); err != nil {
+			return err
+		}
+	}
+	for _, order := range q.Sort {
+		if order == nil {
+			return fmt.Errorf("%w: empty sort", ErrInvalidQuery)
+		}
+		if _, err := column(entity, order.Field); err != nil {
+			return err
+		}
+	}
+	q.entity = entity
+	return nil
+}
+
+// Condition 返回查询条件，没有条件时返回nil
+func (q *Query) Condition() expr.Expr {
+	if q.entity == nil || q.Where == nil {
+		return nil
+	}
+	return q.Where.build(q.entity)
+}
+
+// OrderBy 返回排序表达式，没有排序时返回nil
+func (q *Query) OrderBy() expr.Expr {
+	if q.entity == nil || len(q.Sort) == 0 {
+		return nil
+	}
+	var orders []expr.Expr
+	for _, order := range q.Sort {
+		col := q.entity.Column(order.Field)
+		if order.Desc {
+			orders = append(orders, expr.Desc(col))
+		} else {
+			orders = append(orders, expr.Asc(col))
+		}
+	}
+	return expr.List(keywords.Comma, orders...)
+}
+
+// Filter 将查询应用到语句，可以直接用于BaseMapper.Select、CountBy等方法
+//
+// 查询条件与语句中已有的条件使用AND连接，分页参数为0时保留语句原有的分页
+func (q *Query) Filter() expr.FilterFn {
+	return func(exp expr.Expr) {
+		if q.entity == nil {
+			return
+		}
+		if cond := q.Condition(); cond != nil {
+			switch e := exp.(type) {
+			case *expr.SelectExpr:
+				e.WhereExpr = and(e.WhereExpr, cond)
+			case *expr.UpdateExpr:
+				e.WhereExpr = and(e.WhereExpr, cond)
+			case *expr.DeleteExpr:
+				e.WhereExpr = and(e.WhereExpr, cond)
+			}
+		}
+		s, ok := exp.(*expr.SelectExpr)
+		if !ok {
+			return
+		}
+		if orderBy := q.OrderBy(); orderBy != nil {
+			s.OrderByExpr = orderBy
+		}
+		if q.Limit > 0 {
+			s.Limit(q.Limit)
+		}
+		if q.Offset > 0 {
+			s.Offset(q.Offset)
+		}
+	}
+}
+
+// Select 生成entity的完整查询
+func (q *Query) Select() *expr.SelectExpr {
+	s := expr.Select(q.entity.ColumnExprs()...).From(q.entity)
+	q.Filter()(s)
+	return s
+}
+
+func and(where expr.Expr, cond expr.Expr) expr.Expr {
+	if where == nil {
+		return cond
+	}
+	return expr.And(group(where), group(cond))
+}
+
+// group 为AND/OR条件添加括号，保证嵌套条件的优先级
+func group(exp expr.Expr) expr.Expr {
+	if l, ok := exp.(*expr.ListExpr); ok && (l.Separator == keywords.And || l.Separator == keywords.Or) {
+		return expr.Paren(l)
+	}
+	return exp
+}
+
+func column(entity *meta.Entity, field string) (*meta.Column, error) {
+	col := entity.Column(field)
+	if col == nil || col.Ignore {
+		return nil, fmt.Errorf("%w: field %q is not allowed", ErrInvalidQuery, field)
+	}
+	return col, nil
+}
+
+func (c *Cond) validate(entity *meta.Entity) error {
+	switch c.Op {
+	case OpAnd, OpOr:
+		if len(c.Conds) == 0 {
+			return fmt.Errorf("%w: %s requires conditions", ErrInvalidQuery, c.Op)
+		}
+		for _, sub := range c.Conds {
+			if sub == nil {
+				return fmt.Errorf("%w: empty condition", ErrInvalidQuery)
+			}
+			if err := sub.validate(entity); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OpNot:
+		if len(c.Conds) != 1 || c.Conds[0] == nil {
+			return fmt.Errorf("%w: not requires one condition", ErrInvalidQuery)
+		}
+		return c.Conds[0].validate(entity)
+	}
+	if _, err := column(entity, c.Field); err != nil {
+		return err
+	}
+	switch c.Op {
+	case OpIn, OpNotIn:
+		if len(c.Values) == 0 {
+			return fmt.Errorf("%w: %s requires values", ErrInvalidQuery, c.Op)
+		}
+	case OpBetween:
+		if len(c.Values) != 2 {
+			return fmt.Errorf("%w: between requires two values", ErrInvalidQuery)
+		}
+	case OpNull, OpNotNull:
+		return nil
+	default:
+		if _, ok := binaryOps[c.Op]; !ok {
+			return fmt.Errorf("%w: unknown operator %q", ErrInvalidQuery, c.Op)
+		}
+		//只有eq/ne的空值可以转换为IS NULL/IS NOT NULL
+		if c.Value == nil && c.Op != OpEq && c.Op != OpNe {
+			return fmt.Errorf("%w: %s requires a value", ErrInvalidQuery, c.Op)
+		}
+		return normalize(&c.Value)
+	}
+	for idx := range c.Values {
+		if c.Values[idx] == nil {
+			return fmt.Errorf("%w: %s does not accept null values", ErrInvalidQuery, c.Op)
+		}
+		if err := normalize(&c.Values[idx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalize 只允许标量值和时间，json.Number转换为int64或float64
+func normalize(v *any) error {
+	switch vv := (*v).(type) {
+	case nil, string, bool, int, int64, uint64, float64, time.Time:
+	case json.Number:
+		if n, err := vv.Int64(); err == nil {
+			*v = n
+		} else if f, err := vv.Float64(); err == nil {
+			*v = f
+		} else {
+			return fmt.Errorf("%w: invalid number %s", ErrInvalidQuery, vv)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported value type %T", ErrInvalidQuery, vv)
+	}
+	return nil
+}
+
+func (c *Cond) build(entity *meta.Entity) expr.Expr {
+	switch c.Op {
+	case OpAnd, OpOr:
+		var exps []expr.Expr
+		for _, sub := range c.Conds {
+			exps = append(exps, group(sub.build(entity)))
+		}
+		if len(exps) == 1 {
+			return exps[0]
+		}
+		if c.Op == OpAnd {
+			return expr.And(exps...)
+		}
+		return expr.Or(exps...)
+	case OpNot:
+		return expr.Not(group(c.Conds[0].build(entity)))
+	}
+	col := entity.Column(c.Field)
+	switch c.Op {
+	case OpIn:
+		return expr.In(col, col.ColumnName, c.Values...)
+	case OpNotIn:
+		return expr.NotIn(col, col.ColumnName, c.Values...)
+	case OpBetween:
+		return expr.Between(col, expr.Var(col.ColumnName+"_start", c.Values[0]), expr.Var(col.ColumnName+"_end", c.Values[1]))
+	case OpNull:
+		return expr.IsNull(col)
+	case OpNotNull:
+		return expr.IsNotNull(col)
+	}
+	if c.Value == nil {
+		return expr.Binary(col, binaryOps[c.Op], nil)
+	}
+	return expr.Binary(col, binaryOps[c.Op], expr.Var(col.ColumnName, c.Value))
+}
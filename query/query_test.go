@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/gnodux/sqlmx/meta"
+	"github.com/stretchr/testify/assert"
+)
+
+type User struct {
+	Id        int64
+	Name      string
+	Role      string
+	Age       int
+	CreatedAt time.Time
+	Password  string `dbx:"_"`
+}
+
+var userEntity = meta.NewEntity(&User{})
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		want     string
+		wantArgs map[string]any
+	}{
+		{
+			name:     "conditions",
+			json:     `{"where":{"op":"and","conds":[{"op":"like","field":"name","value":"tom%"},{"op":"or","conds":[{"op":"in","field":"role","values":["admin","root"]},{"op":"ge","field":"Age","value":18}]}]}}`,
+			want:     "SELECT `id`,`name`,`role`,`age`,`created_at`,`password` FROM `user` WHERE `name` LIKE :name AND ( `role` IN ( :role_0,:role_1 ) OR `age` >= :age )",
+			wantArgs: map[string]any{"name": "tom%", "role_0": "admin", "role_1": "root", "age": int64(18)},
+		}, {
+			name:     "sort and pagination",
+			json:     `{"where":{"op":"not","conds":[{"op":"between","field":"age","values":[18,60.5]}]},"sort":[{"field":"created_at","desc":true},{"field":"id"}],"limit":10,"offset":20}`,
+			want:     "SELECT `id`,`name`,`role`,`age`,`created_at`,`password` FROM `user` WHERE NOT `age` BETWEEN :age_start AND :age_end ORDER BY `created_at` DESC,`id` ASC LIMIT :limit OFFSET :offset",
+			wantArgs: map[string]any{"age_start": int64(18), "age_end": 60.5, "limit": 10, "offset": 20},
+		}, {
+			name: "null",
+			json: `{"where":{"op":"and","conds":[{"op":"null","field":"role"},{"op":"notnull","field":"name"},{"op":"eq","field":"age"}]}}`,
+			want: "SELECT `id`,`name`,`role`,`age`,`created_at`,`password` FROM `user` WHERE `role` IS NULL AND `name` IS NOT NULL AND `age` IS NULL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Unmarshal([]byte(tt.json), userEntity)
+			assert.NoError(t, err)
+			got, args, err := expr.NewTracedBuffer(dialect.MySQL).BuildNamed(q.Select())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{name: "unknown field", json: `{"where":{"op":"eq","field":"salary","value":1}}`},
+		{name: "ignored field", json: `{"where":{"op":"eq","field":"password","value":"x"}}`},
+		{name: "qualified field", json: `{"where":{"op":"eq","field":"user.name","value":"x"}}`},
+		{name: "unknown operator", json: `{"where":{"op":"raw","field":"name","value":"1=1"}}`},
+		{name: "object value", json: `{"where":{"op":"eq","field":"name","value":{"raw":"1=1"}}}`},
+		{name: "unknown sort field", json: `{"sort":[{"field":"name; DROP TABLE user"}]}`},
+		{name: "unknown property", json: `{"raw":"1=1"}`},
+		{name: "negative limit", json: `{"limit":-1}`},
+		{name: "limit exceeds max page size", json: `{"limit":1001}`},
+		{name: "between values", json: `{"where":{"op":"between","field":"age","values":[1]}}`},
+		{name: "null value", json: `{"where":{"op":"gt","field":"age","value":null}}`},
+		{name: "missing value", json: `{"where":{"op":"like","field":"name"}}`},
+		{name: "null in values", json: `{"where":{"op":"in","field":"role","values":["admin",null]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.json), userEntity)
+			assert.True(t, errors.Is(err, ErrInvalidQuery), "%v", err)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := expr.Select(userEntity.ColumnExprs()...).From(userEntity).
+		Where(expr.And(
+			expr.Eq(userEntity.Column("role"), expr.Var("role", "admin")),
+			expr.Paren(expr.Or(expr.Name("age").Lt(expr.Var("age", int64(18))), expr.Name("age").IsNull())),
+			expr.Name("name").NotIn("a", "b"),
+		)).
+		OrderBy(expr.Desc(expr.Name("created_at"))).
+		Limit(5)
+	data, err := Marshal(s)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"where":{"op":"and","conds":[{"op":"eq","field":"role","value":"admin"},{"op":"or","conds":[{"op":"lt","field":"age","value":18},{"op":"null","field":"age"}]},{"op":"nin","field":"name","values":["a","b"]}]},"sort":[{"field":"created_at","desc":true}],"limit":5}`, string(data))
+
+	q, err := Unmarshal(data, userEntity)
+	assert.NoError(t, err)
+	buf := expr.NewTracedBuffer(dialect.MySQL)
+	want, wantArgs, _ := buf.Build(s)
+	got, args, err := expr.NewTracedBuffer(dialect.MySQL).Build(q.Select())
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, wantArgs, args)
+
+	again, err := Marshal(q.Select())
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		expr expr.Expr
+	}{
+		{name: "raw", expr: expr.Raw("1=1")},
+		{name: "raw value", expr: expr.Name("id").Eq(expr.Raw("1 OR 1=1"))},
+		{name: "function", expr: expr.Eq(expr.Fn("LOWER", expr.Name("name")), "tom")},
+		{name: "qualified", expr: expr.Name("id", "u").Eq(1)},
+		{name: "sub query", expr: expr.Name("id").In(expr.Select(expr.Name("id")).From(expr.Name("t")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.expr)
+			assert.True(t, errors.Is(err, ErrUnsupportedExpr), "%v", err)
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	q, err := Unmarshal([]byte(`{"where":{"op":"or","conds":[{"op":"eq","field":"name","value":"a"},{"op":"eq","field":"role","value":"b"}]},"limit":10}`), userEntity)
+	assert.NoError(t, err)
+	s := expr.Select(expr.Count).From(userEntity).Where(expr.Name("tenant_id").Eq(expr.Var("tenant_id", 1)))
+	q.Filter()(s)
+	got, _, err := expr.NewTracedBuffer(dialect.MySQL).BuildNamed(s.BuildCountExpr())
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT COUNT(1) FROM `user` WHERE `tenant_id` = :tenant_id AND ( `name` = :name OR `role` = :role )", got)
+}
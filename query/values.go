@@ -0,0 +1,243 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gnodux/sqlmx/meta"
+)
+
+// 查询字符串中的保留参数
+const (
+	//ParamSort 排序，多个字段使用逗号分隔，字段前加-表示降序，例如：sort=-created_at,id
+	ParamSort = "sort"
+	//ParamPage 页码，从1开始
+	ParamPage = "page"
+	//ParamSize 每页的数量
+	ParamSize = "size"
+	//OpSeparator 字段和运算符的分隔符，例如：age__gte=18
+	OpSeparator = "__"
+)
+
+var (
+	//DefaultPageSize 指定了page但没有指定size时的每页数量
+	DefaultPageSize = 20
+	//MaxPageSize 允许的最大每页数量
+	MaxPageSize = 1000
+)
+
+// 查询字符串中的运算符
+var valueOps = map[string]string{
+	"eq":      OpEq,
+	"ne":      OpNe,
+	"gt":      OpGt,
+	"gte":     OpGe,
+	"ge":      OpGe,
+	"lt":      OpLt,
+	"lte":     OpLe,
+	"le":      OpLe,
+	"like":    OpLike,
+	"in":      OpIn,
+	"nin":     OpNotIn,
+	"between": OpBetween,
+	"isnull":  OpNull,
+}
+
+// 日期参数支持的格式
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+// ParseRawQuery 解析URL中的查询字符串，参见ParseValues
+func ParseRawQuery(rawQuery string, entity *meta.Entity) (*Query, error) {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidQuery, err)
+	}
+	return ParseValues(values, entity)
+}
+
+// ParseValues 解析查询字符串形式的过滤、排序和分页参数，例如：
+//
+//	name__like=ab%&age__gte=18&role__in=admin,root&sort=-created_at&page=2&size=10
+//
+// 字段只能是entity中的列，没有运算符时表示等于，多个条件使用AND连接。
+// 支持的运算符：eq、ne、gt、gte、lt、lte、like、in、nin、between(逗号分隔的两个值)、isnull(true/false)，
+// 参数值按照列的类型进行转换，转换失败时返回ErrInvalidQuery
+func ParseValues(values url.Values, entity *meta.Entity) (*Query, error) {
+	if entity == nil {
+		return nil, fmt.Errorf("%w: entity is required", ErrInvalidQuery)
+	}
+	q := &Query{}
+	var conds []*Cond
+	//按参数名称排序，保证生成的语句稳定
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch key {
+		case ParamSort, ParamPage, ParamSize:
+			continue
+		}
+		for _, value := range values[key] {
+			cond, err := parseCond(entity, key, value)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+		}
+	}
+	switch len(conds) {
+	case 0:
+	case 1:
+		q.Where = conds[0]
+	default:
+		q.Where = &Cond{Op: OpAnd, Conds: conds}
+	}
+	for _, field := range splitList(values.Get(ParamSort)) {
+		if strings.HasPrefix(field, "-") {
+			q.Sort = append(q.Sort, &Order{Field: field[1:], Desc: true})
+		} else {
+			q.Sort = append(q.Sort, &Order{Field: strings.TrimPrefix(field, "+")})
+		}
+	}
+	if err := q.parsePage(values.Get(ParamPage), values.Get(ParamSize)); err != nil {
+		return nil, err
+	}
+	if err := q.Bind(entity); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *Query) parsePage(page, size string) error {
+	if page == "" && size == "" {
+		return nil
+	}
+	pageNum, pageSize := 1, DefaultPageSize
+	var err error
+	if page != "" {
+		if pageNum, err = strconv.Atoi(page); err != nil || pageNum < 1 {
+			return fmt.Errorf("%w: invalid page %q", ErrInvalidQuery, page)
+		}
+	}
+	if size != "" {
+		if pageSize, err = strconv.Atoi(size); err != nil || pageSize < 1 || pageSize > MaxPageSize {
+			return fmt.Errorf("%w: invalid size %q", ErrInvalidQuery, size)
+		}
+	}
+	q.Limit = pageSize
+	q.Offset = (pageNum - 1) * pageSize
+	return nil
+}
+
+func parseCond(entity *meta.Entity, key string, value string) (*Cond, error) {
+	field, op := key, OpEq
+	if idx := strings.LastIndex(key, OpSeparator); idx > 0 {
+		name := key[idx+len(OpSeparator):]
+		var ok bool
+		if op, ok = valueOps[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown operator %q", ErrInvalidQuery, name)
+		}
+		field = key[:idx]
+	}
+	col, err := column(entity, field)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case OpNull:
+		isNull, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid isnull value %q", ErrInvalidQuery, value)
+		}
+		if !isNull {
+			op = OpNotNull
+		}
+		return &Cond{Op: op, Field: field}, nil
+	case OpLike:
+		return &Cond{Op: op, Field: field, Value: value}, nil
+	case OpIn, OpNotIn, OpBetween:
+		cond := &Cond{Op: op, Field: field}
+		for _, item := range splitList(value) {
+			v, err := convert(col, item)
+			if err != nil {
+				return nil, err
+			}
+			cond.Values = append(cond.Values, v)
+		}
+		return cond, nil
+	}
+	v, err := convert(col, value)
+	if err != nil {
+		return nil, err
+	}
+	return &Cond{Op: op, Field: field, Value: v}, nil
+}
+
+// convert 按照列的类型转换参数值
+func convert(col *meta.Column, value string) (any, error) {
+	t := col.Type
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return value, nil
+	}
+	var (
+		v   any
+		err error
+	)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(value, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(value, 64)
+	case reflect.Bool:
+		v, err = strconv.ParseBool(value)
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			v, err = parseTime(value)
+		} else {
+			v = value
+		}
+	default:
+		v = value
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid value %q for %s", ErrInvalidQuery, value, col.ColumnName)
+	}
+	return v, nil
+}
+
+func parseTime(value string) (t time.Time, err error) {
+	for _, layout := range timeLayouts {
+		if t, err = time.ParseInLocation(layout, value, time.Local); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// splitList 逗号分隔的列表，忽略空白项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRawQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		want     string
+		wantArgs map[string]any
+	}{
+		{
+			name:     "filters",
+			query:    "name__like=ab%25&age__gte=18&role__in=admin,root&sort=-created_at&page=2",
+			want:     "SELECT `id`,`name`,`role`,`age`,`created_at`,`password` FROM `user` WHERE `age` >= :age AND `name` LIKE :name AND `role` IN ( :role_0,:role_1 ) ORDER BY `created_at` DESC LIMIT :limit OFFSET :offset",
+			wantArgs: map[string]any{"age": int64(18), "name": "ab%", "role_0": "admin", "role_1": "root", "limit": 20, "offset": 20},
+		}, {
+			name:     "equal and null",
+			query:    "Role=admin&name__isnull=false&sort=age,-id&page=3&size=5",
+			want:     "SELECT `id`,`name`,`role`,`age`,`created_at`,`password` FROM `user` WHERE `role` = :role AND `name` IS NOT NULL ORDER BY `age` ASC,`id` DESC LIMIT :limit OFFSET :offset",
+			wantArgs: map[string]any{"role": "admin", "limit": 5, "offset": 10},
+		}, {
+			name:     "between time",
+			query:    "created_at__between=2023-01-01,2023-02-01&id__ne=1",
+			want:     "SELECT `id`,`name`,`role`,`age`,`created_at`,`password` FROM `user` WHERE `created_at` BETWEEN :created_at_start AND :created_at_end AND `id` != :id",
+			wantArgs: map[string]any{"created_at_start": time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local), "created_at_end": time.Date(2023, 2, 1, 0, 0, 0, 0, time.Local), "id": int64(1)},
+		}, {
+			name:  "empty",
+			query: "",
+			want:  "SELECT `id`,`name`,`role`,`age`,`created_at`,`password` FROM `user`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseRawQuery(tt.query, userEntity)
+			assert.NoError(t, err)
+			got, args, err := expr.NewTracedBuffer(dialect.MySQL).BuildNamed(q.Select())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestParseRawQueryInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "unknown field", query: "salary__gt=1"},
+		{name: "ignored field", query: "password=x"},
+		{name: "unknown operator", query: "name__regex=a"},
+		{name: "invalid number", query: "age__gt=abc"},
+		{name: "unknown sort field", query: "sort=-salary"},
+		{name: "invalid page", query: "page=0"},
+		{name: "size too large", query: "size=100000"},
+		{name: "between values", query: "age__between=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRawQuery(tt.query, userEntity)
+			assert.True(t, errors.Is(err, ErrInvalidQuery), "%v", err)
+		})
+	}
+}
@@ -6,22 +6,23 @@
 package sqlmx
 
 import (
-	"github.com/cookieY/sqlx"
 	"reflect"
 )
 
+// getTpl 按顺序查找第一个存在的模版，每个候选模版优先使用当前方言的版本；
+// 只有一个候选模版时直接返回，由DB.ParseSQL在执行时选择方言模版
 func getTpl(d *DB, templateList []string) string {
 	if len(templateList) == 1 {
 		return templateList[0]
-	} else {
-		for _, tpl := range templateList {
-			if d.template.Lookup(tpl) != nil {
-				return tpl
-			}
+	}
+	for _, tpl := range templateList {
+		if name := d.LookupTemplate(tpl); name != "" {
+			return name
 		}
 	}
 	return ""
 }
+
 func SelectWith(p reflect.Type, db *DB, templateList []string, args []any) (any, error) {
 	list := reflect.New(reflect.SliceOf(p))
 	tpl := getTpl(db, templateList)
@@ -48,14 +49,7 @@ func NamedGetWith(p reflect.Type, db *DB, templateList []string, arg any) (any,
 		o = reflect.New(p)
 	}
 	tpl := getTpl(db, templateList)
-	n, err := db.PrepareNamedEx(tpl, arg)
-	if err != nil {
-		return nil, err
-	}
-	defer func(n *sqlx.NamedStmt) {
-		err = n.Close()
-	}(n)
-	err = n.Get(o.Interface(), arg)
+	err := db.NamedGetEx(o.Interface(), tpl, arg)
 	if p.Kind() == reflect.Pointer {
 		return o.Interface(), err
 	} else {
@@ -71,14 +65,7 @@ func GetWith(p reflect.Type, db *DB, templateList []string, args []any) (any, er
 		o = reflect.New(p)
 	}
 	tpl := getTpl(db, templateList)
-	n, err := db.PrepareEx(tpl, args)
-	if err != nil {
-		return nil, err
-	}
-	defer func(n *sqlx.Stmt) {
-		err = n.Close()
-	}(n)
-	err = n.Get(o.Interface(), args...)
+	err := db.GetEx(o.Interface(), tpl, args...)
 	if p.Kind() == reflect.Pointer {
 		return o.Interface(), err
 	} else {
@@ -6,6 +6,7 @@
 package sqlmx
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gnodux/sqlmx/dialect"
 	. "github.com/gnodux/sqlmx/meta"
@@ -16,6 +17,9 @@ import (
 	"time"
 )
 
+// ErrEmptyBindList bind的参数为空列表
+var ErrEmptyBindList = errors.New("bind list is empty")
+
 func MakeFuncMap(driver *dialect.Dialect) template.FuncMap {
 	return template.FuncMap{
 		"where":      func(v any) string { return where(driver, v) },
@@ -24,6 +28,7 @@ func MakeFuncMap(driver *dialect.Dialect) template.FuncMap {
 		//"asc":        func(cols []string) string { return orderByMap(driver, expr.SimpleAsc(cols...)) },
 		//"desc":       func(cols []string) string { return orderByMap(driver, expr.SimpleDesc(cols...)) },
 		"v":          func(v any) string { return sqlValue(driver, v) },
+		"bind":       func(v any) string { return sqlValues(driver, v) },
 		"n":          driver.SQLNameFunc,
 		"sqlName":    driver.SQLNameFunc,
 		"list":       func(v []any) string { return sqlValues(driver, v) },
@@ -31,6 +36,8 @@ func MakeFuncMap(driver *dialect.Dialect) template.FuncMap {
 		"allColumns": func(v []*Column) string { return allColumns(driver, v) },
 		"args":       func(v []*Column) string { return args(driver, v) },
 		"setArgs":    func(v []*Column) string { return sets(v, driver) },
+		"output":     func(v ...*Column) string { return output(driver, v) },
+		"returning":  func(v ...*Column) string { return returning(driver, v) },
 		"orderBy":    func(v map[string]string) string { return orderByMap(driver, v) },
 		"driver":     func() string { return driver.Name },
 		"dialect": func() string {
@@ -39,6 +46,72 @@ func MakeFuncMap(driver *dialect.Dialect) template.FuncMap {
 	}
 }
 
+// argBinder 参数绑定模式下的bind函数，输出当前方言的占位符并按顺序收集参数，由DB.ParseSQLArgs在模版副本中替换；
+// 直接执行模版(例如Template().ExecuteTemplate)时bind退化为内联值，与list相同
+type argBinder struct {
+	driver *dialect.Dialect
+	//args 收集的参数，模版使用了bind时不为nil
+	args []any
+}
+
+// bind 绑定一个参数，切片(除[]byte外)展开为逗号分隔的多个参数，只能用于IN条件：
+//
+//	WHERE `id` IN ({{bind .ids}}) AND `name` = {{bind .name}}
+//
+// 空列表无法生成正确的IN/NOT IN条件，返回错误，模版中需要先判断：{{if .ids}}...{{end}}
+func (b *argBinder) bind(v any) (string, error) {
+	if b.args == nil {
+		b.args = []any{}
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		if value.Len() == 0 {
+			return "", ErrEmptyBindList
+		}
+		sb := strings.Builder{}
+		for idx := 0; idx < value.Len(); idx++ {
+			if idx > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(b.add(value.Index(idx).Interface()))
+		}
+		return sb.String(), nil
+	}
+	return b.add(v), nil
+}
+
+func (b *argBinder) add(v any) string {
+	b.args = append(b.args, v)
+	return b.driver.BindVar(len(b.args))
+}
+
+// boundTemplate 替换了bind函数的模版集合副本，由DB.bindPool复用
+type boundTemplate struct {
+	tpl    *template.Template
+	binder *argBinder
+	//version 复制时模版集合的版本
+	version uint64
+}
+
+// boundTemplate 从bindPool中获取当前版本的模版副本，模版变化前的副本直接丢弃
+func (d *DB) boundTemplate() (*boundTemplate, error) {
+	version := d.tplVersion.Load()
+	if bound, ok := d.bindPool.Get().(*boundTemplate); ok && bound.version == version {
+		return bound, nil
+	}
+	tpl, err := d.Template().Clone()
+	if err != nil {
+		return nil, err
+	}
+	binder := &argBinder{driver: d.driver}
+	tpl.Funcs(template.FuncMap{"bind": binder.bind})
+	return &boundTemplate{tpl: tpl, binder: binder, version: version}, nil
+}
+
 func orderByMap(driver *dialect.Dialect, order map[string]string) string {
 	if len(order) == 0 {
 		return ""
@@ -61,6 +134,46 @@ func namedWhere(driver *dialect.Dialect, v any) string {
 	return whereWith(driver, v, driver.KeywordWithSpace("AND"), true)
 }
 
+// output 插入语句中返回列的OUTPUT子句(SQLServer)，其他方言返回空字符串
+func output(driver *dialect.Dialect, cols []*Column) string {
+	if driver.Returning != dialect.ReturningOutput {
+		return ""
+	}
+	sb := strings.Builder{}
+	pre := driver.KeywordWith("", "OUTPUT", " ")
+	for _, c := range cols {
+		if c == nil {
+			continue
+		}
+		sb.WriteString(pre)
+		sb.WriteString(driver.KeywordWith("", "INSERTED", "."))
+		sb.WriteString(driver.SQLNameFunc(c.ColumnName))
+		pre = ","
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	return sb.String()
+}
+
+// returning 语句末尾返回列的RETURNING子句(Postgres)，其他方言返回空字符串
+func returning(driver *dialect.Dialect, cols []*Column) string {
+	if driver.Returning != dialect.ReturningClause {
+		return ""
+	}
+	sb := strings.Builder{}
+	pre := driver.KeywordWithSpace("RETURNING")
+	for _, c := range cols {
+		if c == nil {
+			continue
+		}
+		sb.WriteString(pre)
+		sb.WriteString(driver.SQLNameFunc(c.ColumnName))
+		pre = ","
+	}
+	return sb.String()
+}
+
 func columns(driver *dialect.Dialect, cols []*Column) string {
 	sb := strings.Builder{}
 	pre := ""
@@ -6,6 +6,7 @@
 package sqlmx
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"os"
 	"reflect"
@@ -13,7 +14,9 @@ import (
 	"testing"
 	"text/template"
 
+	"github.com/gnodux/sqlmx/builtin"
 	"github.com/gnodux/sqlmx/dialect"
+	"github.com/gnodux/sqlmx/meta"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +70,156 @@ func TestMapLength(t *testing.T) {
 //func TestPg(t *testing.T) {
 //	c, err := sql.Open("postgres", "")
 //}
+
+func TestCreateTemplateReturning(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect *dialect.Dialect
+		want    string
+		notWant string
+	}{
+		{name: "mysql", dialect: dialect.MySQL, notWant: "RETURNING"},
+		{name: "postgres", dialect: dialect.Postgres, want: ` RETURNING "id"`},
+		{name: "sql server", dialect: dialect.SQLServer, want: "OUTPUT INSERTED.[id] VALUES"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := template.New("tests").Funcs(MakeFuncMap(tt.dialect)).ParseFS(builtin.Builtin, "builtin/create.sql")
+			assert.NoError(t, err)
+			buf := &strings.Builder{}
+			assert.NoError(t, tpl.ExecuteTemplate(buf, "create.sql", meta.NewEntity(&Role{})))
+			if tt.want != "" {
+				assert.Contains(t, buf.String(), tt.want)
+			}
+			if tt.notWant != "" {
+				assert.NotContains(t, buf.String(), tt.notWant)
+			}
+		})
+	}
+}
+
+func TestParseSQLBind(t *testing.T) {
+	const tpl = `SELECT * FROM {{n "user"}} WHERE {{n "name"}} = {{bind .name}} AND {{n "id"}} IN ({{bind .ids}})`
+	tests := []struct {
+		name     string
+		dialect  *dialect.Dialect
+		arg      any
+		want     string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name:     "mysql",
+			dialect:  dialect.MySQL,
+			arg:      map[string]any{"name": "o'neil", "ids": []int{1, 2}},
+			want:     "SELECT * FROM `user` WHERE `name` = ? AND `id` IN (?,?)",
+			wantArgs: []any{"o'neil", 1, 2},
+		},
+		{
+			name:     "postgres",
+			dialect:  dialect.Postgres,
+			arg:      map[string]any{"name": `a\b`, "ids": []int{1, 2}},
+			want:     `SELECT * FROM "user" WHERE "name" = $1 AND "id" IN ($2,$3)`,
+			wantArgs: []any{`a\b`, 1, 2},
+		},
+		{
+			name:    "empty list",
+			dialect: dialect.Postgres,
+			arg:     map[string]any{"name": "tom", "ids": []int{}},
+			wantErr: ErrEmptyBindList,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DB{driver: tt.dialect}
+			d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(tt.dialect)))
+			_, err := d.ParseTemplate("bind.sql", tpl)
+			assert.NoError(t, err)
+			query, bindArgs, err := d.ParseSQLArgs("bind.sql", tt.arg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, query)
+			assert.Equal(t, tt.wantArgs, bindArgs)
+			//不能传递绑定参数的调用方式返回错误
+			_, err = d.ParseSQL("bind.sql", tt.arg)
+			assert.ErrorIs(t, err, ErrBindArgs)
+		})
+	}
+	t.Run("without bind", func(t *testing.T) {
+		d := &DB{driver: dialect.MySQL}
+		d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(dialect.MySQL)))
+		_, err := d.ParseTemplate("plain.sql", "SELECT * FROM `user` WHERE `id` = ?")
+		assert.NoError(t, err)
+		query, bindArgs, err := d.ParseSQLArgs("plain.sql", []any{1})
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM `user` WHERE `id` = ?", query)
+		assert.Nil(t, bindArgs)
+	})
+	t.Run("template changed", func(t *testing.T) {
+		d := &DB{driver: dialect.MySQL}
+		d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(dialect.MySQL)))
+		_, err := d.ParseTemplate("a.sql", "SELECT {{bind .}}")
+		assert.NoError(t, err)
+		_, _, err = d.ParseSQLArgs("a.sql", 1)
+		assert.NoError(t, err)
+		//复用的模版副本需要包含后续解析的模版
+		_, err = d.ParseTemplate("b.sql", "SELECT {{bind .}},{{bind .}}")
+		assert.NoError(t, err)
+		query, bindArgs, err := d.ParseSQLArgs("b.sql", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT ?,?", query)
+		assert.Equal(t, []any{2, 2}, bindArgs)
+	})
+}
+
+func TestNamedBindArgs(t *testing.T) {
+	d := newFakeDB(t, dialect.MySQL, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		return nil, nil, nil
+	})
+	_, err := d.ParseTemplate("bind.sql", "DELETE FROM `user` WHERE `id` IN ({{bind .ids}})")
+	assert.NoError(t, err)
+	arg := map[string]any{"ids": []int{1, 2}}
+	_, err = d.NamedExecEx("bind.sql", arg)
+	assert.ErrorIs(t, err, ErrBindArgs)
+	_, err = d.PrepareNamedEx("bind.sql", arg)
+	assert.ErrorIs(t, err, ErrBindArgs)
+}
+
+func TestLookupDialectTemplate(t *testing.T) {
+	templates := map[string]string{
+		"user/get_user.sql":            "SELECT * FROM `user` LIMIT 1",
+		"user/get_user.postgres.sql":   `SELECT * FROM "user" LIMIT 1`,
+		"get_user.mssql.sql":           "SELECT TOP 1 * FROM [user]",
+		"user/count_user.sql":          "SELECT COUNT(*) FROM `user`",
+		"user/count_user.postgres.sql": `SELECT COUNT(*) FROM "user"`,
+	}
+	candidates := []string{"my_mapper/get_user.sql", "user/get_user.sql", "get_user.sql"}
+	tests := []struct {
+		name     string
+		dialect  *dialect.Dialect
+		tpl      string
+		want     string
+		wantList string
+	}{
+		{name: "mysql", dialect: dialect.MySQL, tpl: "user/get_user.sql", want: "SELECT * FROM `user` LIMIT 1", wantList: "user/get_user.sql"},
+		{name: "postgres", dialect: dialect.Postgres, tpl: "user/get_user.sql", want: `SELECT * FROM "user" LIMIT 1`, wantList: "user/get_user.postgres.sql"},
+		{name: "sql server", dialect: dialect.SQLServer, tpl: "user/count_user.sql", want: "SELECT COUNT(*) FROM `user`", wantList: "user/get_user.sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DB{driver: tt.dialect}
+			d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(tt.dialect)))
+			for name, tpl := range templates {
+				_, err := d.ParseTemplate(name, tpl)
+				assert.NoError(t, err)
+			}
+			query, err := d.ParseSQL(tt.tpl, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, query)
+			assert.Equal(t, tt.wantList, getTpl(d, candidates))
+		})
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MetaTimeout 执行超时时间，例如：-- @timeout 2s
+	MetaTimeout = "timeout"
+	// MetaReadonly 只读，只读模版不能使用ExecEx/NamedExecEx执行，事务使用只读事务，例如：-- @readonly
+	MetaReadonly = "readonly"
+	// MetaDS 执行模版的数据源，例如：-- @ds replica
+	MetaDS = "ds"
+	// MetaResult 结果数量：one/many，例如：-- @result one
+	MetaResult = "result"
+
+	// ResultOne 返回单条记录
+	ResultOne = "one"
+	// ResultMany 返回多条记录
+	ResultMany = "many"
+)
+
+var (
+	//ErrInvalidTplMeta 模版头部的元数据不合法
+	ErrInvalidTplMeta = errors.New("invalid template metadata")
+	//ErrReadonlyTemplate 只读模版不能用于执行更新
+	ErrReadonlyTemplate = errors.New("template is readonly")
+)
+
+// TplMeta 模版的元数据，在模版开头的注释中声明模版的执行策略：
+//
+//	-- 根据ID查询用户
+//	-- @timeout 2s
+//	-- @readonly
+//	-- @ds replica
+//	-- @result one
+//	SELECT * FROM `user` WHERE `id`={{bind .id}}
+type TplMeta struct {
+	//Timeout 执行超时时间，0表示不限制
+	Timeout time.Duration
+	//Readonly 只读
+	Readonly bool
+	//DS 执行模版的数据源(DBManager中的名称)，为空时使用当前数据库
+	DS string
+	//Result 结果数量：ResultOne/ResultMany，为空时不限制
+	Result string
+}
+
+// parseTplMeta 解析模版开头的注释块中以@开头的元数据，没有元数据时返回nil
+// 未定义的@key(例如文档注释中的@param、@author)不是元数据，忽略
+func parseTplMeta(tpl string) (*TplMeta, error) {
+	var meta *TplMeta
+	for _, line := range strings.Split(tpl, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		key, value, _ := strings.Cut(line[1:], " ")
+		value = strings.TrimSpace(value)
+		switch key {
+		case MetaTimeout, MetaReadonly, MetaDS, MetaResult:
+			if meta == nil {
+				meta = &TplMeta{}
+			}
+		default:
+			continue
+		}
+		switch key {
+		case MetaTimeout:
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("%w: invalid timeout %q", ErrInvalidTplMeta, value)
+			}
+			meta.Timeout = timeout
+		case MetaReadonly:
+			meta.Readonly = true
+			if value != "" {
+				readonly, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid readonly %q", ErrInvalidTplMeta, value)
+				}
+				meta.Readonly = readonly
+			}
+		case MetaDS:
+			if value == "" {
+				return nil, fmt.Errorf("%w: empty ds", ErrInvalidTplMeta)
+			}
+			meta.DS = value
+		case MetaResult:
+			if value != ResultOne && value != ResultMany {
+				return nil, fmt.Errorf("%w: invalid result %q", ErrInvalidTplMeta, value)
+			}
+			meta.Result = value
+		}
+	}
+	return meta, nil
+}
+
+// TemplateMeta 模版的元数据，name为模版名称，模版没有声明元数据时返回nil
+func (d *DB) TemplateMeta(name string) *TplMeta {
+	if meta, ok := d.metas.Load(name); ok {
+		return meta.(*TplMeta)
+	}
+	return nil
+}
+
+// setTemplateMeta 更新模版的元数据，meta为nil时删除
+func (d *DB) setTemplateMeta(name string, meta *TplMeta) {
+	if meta == nil {
+		d.metas.Delete(name)
+	} else {
+		d.metas.Store(name, meta)
+	}
+}
+
+// metaOf 模版(包括方言模版)的元数据，inline SQL没有元数据
+func (d *DB) metaOf(sqlOrTpl string) *TplMeta {
+	if !strings.HasSuffix(sqlOrTpl, sqlSuffix) {
+		return nil
+	}
+	if name := d.LookupTemplate(sqlOrTpl); name != "" {
+		return d.TemplateMeta(name)
+	}
+	return nil
+}
+
+// execContext 根据模版的元数据选择执行的数据库，并创建带有超时的上下文
+// write: 是否为更新操作，只读模版不允许更新
+func (d *DB) execContext(sqlOrTpl string, write bool) (*DB, context.Context, context.CancelFunc, error) {
+	meta := d.metaOf(sqlOrTpl)
+	if meta == nil {
+		return d, context.Background(), func() {}, nil
+	}
+	if write && meta.Readonly {
+		return nil, nil, nil, fmt.Errorf("%w: %s", ErrReadonlyTemplate, sqlOrTpl)
+	}
+	db := d
+	if meta.DS != "" && d.m != nil {
+		var err error
+		if db, err = d.m.Get(meta.DS); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	ctx, cancel := meta.context()
+	return db, ctx, cancel, nil
+}
+
+// context 创建带有模版超时时间的上下文
+func (m *TplMeta) context() (context.Context, context.CancelFunc) {
+	if m == nil || m.Timeout <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), m.Timeout)
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTplMeta(t *testing.T) {
+	tests := []struct {
+		name    string
+		tpl     string
+		want    *TplMeta
+		wantErr bool
+	}{
+		{name: "no meta", tpl: "SELECT * FROM `user`"},
+		{name: "plain comment", tpl: "-- list users\nSELECT * FROM `user`"},
+		{
+			name: "all",
+			tpl:  "-- get user by id\n-- @timeout 2s\n-- @readonly\n--@ds replica\n-- @result one\nSELECT * FROM `user` WHERE `id`=?",
+			want: &TplMeta{Timeout: 2 * time.Second, Readonly: true, DS: "replica", Result: ResultOne},
+		},
+		{name: "readonly false", tpl: "-- @readonly false\nSELECT 1", want: &TplMeta{}},
+		{name: "only leading comments", tpl: "SELECT 1\n-- @timeout 2s", want: nil},
+		{name: "invalid timeout", tpl: "-- @timeout soon\nSELECT 1", wantErr: true},
+		{name: "invalid result", tpl: "-- @result some\nSELECT 1", wantErr: true},
+		{name: "unknown", tpl: "-- @param id user id\n-- @author tom\nSELECT 1", want: nil},
+		{name: "unknown with meta", tpl: "-- @param id\n-- @timeout 2s\nSELECT 1", want: &TplMeta{Timeout: 2 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTplMeta(tt.tpl)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTplMeta)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTemplateMetaExec(t *testing.T) {
+	newDB := func() *DB {
+		d := &DB{driver: dialect.MySQL}
+		d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(dialect.MySQL)))
+		return d
+	}
+	m := NewDBManager("meta")
+	primary, replica := newDB(), newDB()
+	m.Set("primary", primary)
+	m.Set("replica", replica)
+	_, err := primary.ParseTemplate("user/list_user.sql", "-- @ds replica\n-- @timeout 1s\nSELECT * FROM `user`")
+	assert.NoError(t, err)
+	_, err = primary.ParseTemplate("user/count_user.sql", "-- @readonly\nSELECT COUNT(*) FROM `user`")
+	assert.NoError(t, err)
+
+	db, ctx, cancel, err := primary.execContext("user/list_user.sql", false)
+	assert.NoError(t, err)
+	defer cancel()
+	assert.Same(t, replica, db)
+	_, ok := ctx.Deadline()
+	assert.True(t, ok)
+
+	db, _, cancel, err = primary.execContext("SELECT * FROM `user`", true)
+	assert.NoError(t, err)
+	defer cancel()
+	assert.Same(t, primary, db)
+
+	_, err = primary.ExecEx("user/count_user.sql")
+	assert.ErrorIs(t, err, ErrReadonlyTemplate)
+
+	type userMapper struct {
+		Count SelectFunc[int] `sql:"-- @result one\nSELECT COUNT(*) FROM user"`
+	}
+	_, err = NewMapperWith[userMapper](m, "primary")
+	assert.ErrorContains(t, err, "SelectFunc conflicts with template @result one")
+}
+
+func TestTxTemplateMeta(t *testing.T) {
+	var executed int
+	d := newFakeDB(t, dialect.MySQL, func(query string, args []driver.Value) ([]string, [][]driver.Value, error) {
+		executed++
+		return []string{"count"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	_, err := d.ParseTemplate("user/count_user.sql", "-- @readonly\nSELECT COUNT(*) FROM `user`")
+	assert.NoError(t, err)
+	_, err = d.ParseTemplate("user/slow_count.sql", "-- @timeout 1ns\nSELECT COUNT(*) FROM `user`")
+	assert.NoError(t, err)
+
+	tx, err := d.BeginTxx(context.Background(), nil)
+	assert.NoError(t, err)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	wrapped := NewTxWith(tx, d, "")
+	_, err = wrapped.ExecEx("user/count_user.sql")
+	assert.ErrorIs(t, err, ErrReadonlyTemplate)
+	_, err = wrapped.NamedExecEx("user/count_user.sql", map[string]any{})
+	assert.ErrorIs(t, err, ErrReadonlyTemplate)
+	var count int
+	assert.NoError(t, wrapped.GetEx(&count, "user/count_user.sql"))
+	assert.Equal(t, 1, count)
+	assert.ErrorIs(t, wrapped.GetEx(&count, "user/slow_count.sql"), context.DeadlineExceeded)
+	assert.Equal(t, 1, executed)
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidInterval 模版热加载的检查间隔必须大于0
+var ErrInvalidInterval = errors.New("invalid watch interval")
+
+// tplFile 模版文件状态，用于检查模版是否变化
+type tplFile struct {
+	fs      *TplFS
+	modTime time.Time
+	size    int64
+}
+
+// templateName 模版文件对应的模版名称，保留目录结构并统一使用/分隔
+func templateName(path string) string {
+	return strings.ReplaceAll(path, "\\", "/")
+}
+
+// ReloadTemplates 重新解析模版(名称=>模版内容)并原子替换当前的模版集合
+//
+// 解析在模版集合的副本上进行，解析失败的模版保留之前的版本，其他模版正常替换，所有错误合并后返回
+func (d *DB) ReloadTemplates(sources map[string]string) error {
+	if d == nil {
+		return ErrNilDB
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	tpl, err := d.Template().Clone()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	metas := map[string]*TplMeta{}
+	for name, src := range sources {
+		meta, err := parseTplMeta(src)
+		if err == nil {
+			_, err = tpl.New(name).Parse(src)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reload template %s: %w", name, err))
+			continue
+		}
+		metas[name] = meta
+		log.Info("reload sql:", name)
+	}
+	d.storeTemplate(tpl, metas)
+	return errors.Join(errs...)
+}
+
+// ReloadTemplateFS 检查SetTemplateFS注册的模版文件，新增或修改的模版重新解析到所有已打开的数据库
+//
+// 第一次调用时只记录模版文件的状态。模版文件的变化通过修改时间和大小判断，删除的模版不会从数据库中移除；
+// 解析失败的模版保留之前的版本，修改后在下次检查时重新解析
+func (m *DBManager) ReloadTemplateFS() error {
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+	files, err := scanTemplateFS(m.templateFS)
+	if err != nil {
+		return err
+	}
+	last := m.tplFiles
+	m.tplFiles = files
+	if last == nil {
+		return nil
+	}
+	sources := map[string]string{}
+	for name, file := range files {
+		if old, ok := last[name]; ok && old.fs == file.fs && old.size == file.size && old.modTime.Equal(file.modTime) {
+			continue
+		}
+		buf, err := fs.ReadFile(file.fs.FS, name)
+		if err != nil {
+			return err
+		}
+		sources[name] = string(buf)
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	m.lock.RLock()
+	dbs := make([]*DB, 0, len(m.dbs))
+	for _, db := range m.dbs {
+		dbs = append(dbs, db)
+	}
+	m.lock.RUnlock()
+	var errs []error
+	for _, db := range dbs {
+		if err = db.ReloadTemplates(sources); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WatchTemplateFS 开启模版热加载(用于开发环境)，每隔interval调用一次ReloadTemplateFS
+//
+// BoostMapper绑定的mapper在执行时按名称查找模版，替换后的下次调用即使用新的SQL，无需重启。
+// 返回的函数用于停止检查，interval必须大于0，否则返回ErrInvalidInterval
+func (m *DBManager) WatchTemplateFS(interval time.Duration) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInterval, interval)
+	}
+	if err := m.ReloadTemplateFS(); err != nil {
+		log.Error("reload template error:", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := m.ReloadTemplateFS(); err != nil {
+					log.Error("reload template error:", err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}, nil
+}
+
+// scanTemplateFS 模版文件的状态，多个TplFS中存在同名模版时与OpenWith一致，后注册的优先
+func scanTemplateFS(list []*TplFS) (map[string]tplFile, error) {
+	files := map[string]tplFile{}
+	for _, tfs := range list {
+		for _, pattern := range tfs.Patterns {
+			matches, err := fs.Glob(tfs.FS, pattern)
+			if err != nil {
+				return nil, err
+			}
+			for _, mf := range matches {
+				info, err := fs.Stat(tfs.FS, mf)
+				if err != nil {
+					return nil, err
+				}
+				files[templateName(mf)] = tplFile{fs: tfs, modTime: info.ModTime(), size: info.Size()}
+			}
+		}
+	}
+	return files, nil
+}
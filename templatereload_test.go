@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2023.
+ * all right reserved by gnodux<gnodux@gmail.com>
+ */
+
+package sqlmx
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"text/template"
+	"time"
+
+	"github.com/gnodux/sqlmx/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadTemplateFS(t *testing.T) {
+	now := time.Now()
+	files := fstest.MapFS{
+		"sql/get_user.sql": {Data: []byte("SELECT * FROM `user` WHERE `id`=?"), ModTime: now},
+	}
+	m := NewDBManager("reload")
+	m.SetTemplateFS(files, "sql/*.sql")
+	d := &DB{driver: dialect.MySQL}
+	d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(dialect.MySQL)))
+	assert.NoError(t, d.ParseTemplateFS(files, "sql/*.sql"))
+	_, err := d.ParseTemplate("inline/count.sql", "SELECT COUNT(*) FROM `user`")
+	assert.NoError(t, err)
+	m.Set("db", d)
+	assert.NoError(t, m.ReloadTemplateFS())
+
+	parse := func(name string) string {
+		query, err := d.ParseSQL(name, nil)
+		assert.NoError(t, err)
+		return query
+	}
+
+	files["sql/get_user.sql"] = &fstest.MapFile{Data: []byte("SELECT `id`,`name` FROM `user` WHERE `id`=?"), ModTime: now.Add(time.Second)}
+	files["sql/new_user.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO `user`(`name`) VALUES(?)"), ModTime: now}
+	assert.NoError(t, m.ReloadTemplateFS())
+	assert.Equal(t, "SELECT `id`,`name` FROM `user` WHERE `id`=?", parse("sql/get_user.sql"))
+	assert.Equal(t, "INSERT INTO `user`(`name`) VALUES(?)", parse("sql/new_user.sql"))
+	//BoostMapper解析的inline模版在替换后依然存在
+	assert.Equal(t, "SELECT COUNT(*) FROM `user`", parse("inline/count.sql"))
+
+	//解析失败时保留之前的版本
+	files["sql/get_user.sql"] = &fstest.MapFile{Data: []byte("SELECT {{if}} FROM `user`"), ModTime: now.Add(2 * time.Second)}
+	assert.Error(t, m.ReloadTemplateFS())
+	assert.Equal(t, "SELECT `id`,`name` FROM `user` WHERE `id`=?", parse("sql/get_user.sql"))
+
+	//没有变化时不重新解析
+	assert.NoError(t, m.ReloadTemplateFS())
+}
+
+func TestWatchTemplateFSInterval(t *testing.T) {
+	m := NewDBManager("watch")
+	stop, err := m.WatchTemplateFS(0)
+	assert.ErrorIs(t, err, ErrInvalidInterval)
+	assert.Nil(t, stop)
+	stop, err = m.WatchTemplateFS(time.Hour)
+	assert.NoError(t, err)
+	stop()
+}
+
+func TestParseTemplateCopyOnWrite(t *testing.T) {
+	d := &DB{driver: dialect.MySQL}
+	d.SetTemplate(template.New("sql").Funcs(MakeFuncMap(dialect.MySQL)))
+	_, err := d.ParseTemplate("sql/get_user.sql", "SELECT * FROM `user` WHERE `id`={{bind .id}}")
+	assert.NoError(t, err)
+
+	//解析不修改正在使用的模版集合
+	old := d.Template()
+	_, err = d.ParseTemplate("sql/count_user.sql", "SELECT COUNT(*) FROM `user`")
+	assert.NoError(t, err)
+	assert.Nil(t, old.Lookup("sql/count_user.sql"))
+	assert.NotNil(t, d.Template().Lookup("sql/count_user.sql"))
+
+	//ParseTemplateFS任一模版解析失败时不替换模版集合
+	files := fstest.MapFS{
+		"fs/a.sql": {Data: []byte("SELECT 1")},
+		"fs/b.sql": {Data: []byte("SELECT {{if}}")},
+	}
+	assert.Error(t, d.ParseTemplateFS(files, "fs/*.sql"))
+	assert.Equal(t, "", d.LookupTemplate("fs/a.sql"))
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 8; idx++ {
+		wg.Add(2)
+		go func(idx int) {
+			defer wg.Done()
+			_, err := d.ParseTemplate(fmt.Sprintf("sql/t%d.sql", idx), "SELECT {{bind .id}}")
+			assert.NoError(t, err)
+		}(idx)
+		go func() {
+			defer wg.Done()
+			query, args, err := d.ParseSQLArgs("sql/get_user.sql", map[string]any{"id": 1})
+			assert.NoError(t, err)
+			assert.Equal(t, "SELECT * FROM `user` WHERE `id`=?", query)
+			assert.Equal(t, []any{1}, args)
+		}()
+	}
+	wg.Wait()
+}
@@ -6,7 +6,9 @@
 package sqlmx
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/cookieY/sqlx"
 	"github.com/gnodux/sqlmx/expr"
 )
@@ -28,6 +30,14 @@ func (t *Tx) Parse(tplName string, args any) (string, error) {
 	return t.db.ParseSQL(tplName, args)
 }
 
+// ParseArgs 解析模版，同时返回bind绑定的参数，参见DB.ParseSQLArgs
+func (t *Tx) ParseArgs(tplName string, args any) (query string, bindArgs []any, err error) {
+	if t.db == nil {
+		return "", nil, ErrNilDB
+	}
+	return t.db.ParseSQLArgs(tplName, args)
+}
+
 // SelectExpr 使用表达式进行查询
 func (t *Tx) SelectExpr(dest interface{}, exp expr.Expr) error {
 	if t == nil {
@@ -179,23 +189,49 @@ func (t *Tx) PrepareEx(query string) (*sqlx.Stmt, error) {
 	return t.Tx.Preparex(query)
 }
 
+// execContext 根据模版的元数据创建带有超时的上下文，只读模版不允许更新
+// 事务已经绑定了数据库连接，因此忽略模版声明的数据源(@ds)
+func (t *Tx) execContext(sqlOrTpl string, write bool) (context.Context, context.CancelFunc, error) {
+	if t.db == nil {
+		return nil, nil, ErrNilDB
+	}
+	meta := t.db.metaOf(sqlOrTpl)
+	if write && meta != nil && meta.Readonly {
+		return nil, nil, fmt.Errorf("%w: %s", ErrReadonlyTemplate, sqlOrTpl)
+	}
+	ctx, cancel := meta.context()
+	return ctx, cancel, nil
+}
+
 // NamedExecEx  use tpl to query named statement
 func (t *Tx) NamedExecEx(sqlOrTpl string, arg interface{}) (sql.Result, error) {
+	ctx, cancel, err := t.execContext(sqlOrTpl, true)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
 	query, err := t.Parse(sqlOrTpl, arg)
 	if err != nil {
 		return nil, err
 	}
 	log.Debug("named exec tpl:", query, arg)
-	return t.NamedExec(query, arg)
+	return t.NamedExecContext(ctx, query, arg)
 }
 
+// ExecEx 使用模版或SQL执行更新，模版声明的@timeout、@readonly生效，@ds被忽略
 func (t *Tx) ExecEx(sqlOrTpl string, args ...interface{}) (sql.Result, error) {
-	query, err := t.Parse(sqlOrTpl, args)
+	ctx, cancel, err := t.execContext(sqlOrTpl, true)
 	if err != nil {
 		return nil, err
 	}
+	defer cancel()
+	query, bindArgs, err := t.ParseArgs(sqlOrTpl, args)
+	if err != nil {
+		return nil, err
+	}
+	args = execArgs(bindArgs, args)
 	log.Debug("exec query:", query, args)
-	return t.Exec(query, args...)
+	return t.ExecContext(ctx, query, args...)
 }
 
 // ExecCurrent use current tpl to exec
@@ -208,13 +244,20 @@ func (t *Tx) NamedExecCurrent(arg interface{}) (sql.Result, error) {
 	return t.NamedExecEx(t.tpl, arg)
 }
 
+// GetEx 使用模版或SQL查询单条记录，模版声明的@timeout生效，@ds被忽略
 func (t *Tx) GetEx(dest any, tpl string, args ...any) error {
-	query, err := t.Parse(tpl, args)
+	ctx, cancel, err := t.execContext(tpl, false)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	query, bindArgs, err := t.ParseArgs(tpl, args)
 	if err != nil {
 		return err
 	}
+	args = execArgs(bindArgs, args)
 	log.Debug("get query:", query, args)
-	return t.Get(dest, query, args...)
+	return t.GetContext(ctx, dest, query, args...)
 }
 
 func NewTxWith(tx *sqlx.Tx, d *DB, tpl string) *Tx {
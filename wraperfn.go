@@ -8,7 +8,6 @@ package sqlmx
 import (
 	"context"
 	"database/sql"
-	"github.com/cookieY/sqlx"
 )
 
 const (
@@ -27,12 +26,21 @@ type GetFunc[T any] func(args ...any) (T, error)
 // NamedGetFunc NamedGet 函数类型, 用于查询单条记录,使用命名参数
 type NamedGetFunc[T any] func(arg any) (T, error)
 
+// NamedSelectArgFunc 指定参数类型的NamedSelectFunc，严格模式下检查SQL中的命名参数是否都是参数类型A的字段
+type NamedSelectArgFunc[A, T any] func(arg A) ([]T, error)
+
+// NamedGetArgFunc 指定参数类型的NamedGetFunc，严格模式下检查SQL中的命名参数是否都是参数类型A的字段
+type NamedGetArgFunc[A, T any] func(arg A) (T, error)
+
 // ExecFunc Exec 函数类型, 用于执行无返回值的SQL
 type ExecFunc func(args ...any) (sql.Result, error)
 
 // NamedExecFunc NamedExec 函数类型, 用于执行无返回值的SQL,使用命名参数
 type NamedExecFunc func(arg any) (sql.Result, error)
 
+// NamedExecArgFunc 指定参数类型的NamedExecFunc，严格模式下检查SQL中的命名参数是否都是参数类型A的字段
+type NamedExecArgFunc[A any] func(arg A) (sql.Result, error)
+
 // TxFunc Tx 函数类型, 用于执行事务
 type TxFunc func(func(*Tx) error) error
 
@@ -105,14 +113,7 @@ func NewNamedGetFuncWith[T any](m *DBManager, db, tpl string) NamedGetFunc[T] {
 		if d, err = m.Get(db); err != nil {
 			return
 		}
-		var n *sqlx.NamedStmt
-		if n, err = d.PrepareNamedEx(tpl, arg); err != nil {
-			return v, err
-		}
-		defer func(n *sqlx.NamedStmt) {
-			err = n.Close()
-		}(n)
-		err = n.Get(&v, arg)
+		err = d.NamedGetEx(&v, tpl, arg)
 		return v, err
 	}
 }